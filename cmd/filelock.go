@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// An exclusive advisory lock on a file, shared between processes.
+// Data files are replaced by rename when saved, so the lock is taken on a
+// separate ".lock" file next to them rather than on the data itself.
+type fileLock struct {
+	f *os.File
+}
+
+// Returned by tryLockFile when another process holds the lock
+var errLocked = errors.New("locked by another process")
+
+func openLockFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+}
+
+// Lock path, waiting as long as another process holds it
+func lockFile(path string) (*fileLock, error) {
+	f, err := openLockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockHandle(f, true); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileLock{f: f}, nil
+}
+
+// Lock path, giving up with errLocked if it is still held after wait
+func tryLockFile(path string, wait time.Duration) (*fileLock, error) {
+	f, err := openLockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		err := lockHandle(f, false)
+		if err == nil {
+			return &fileLock{f: f}, nil
+		}
+		if !errors.Is(err, errLocked) || time.Now().After(deadline) {
+			f.Close()
+			return nil, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Release the lock
+func (l *fileLock) unlock() {
+	unlockHandle(l.f)
+	l.f.Close()
+}
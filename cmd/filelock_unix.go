@@ -0,0 +1,29 @@
+//go:build unix
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func lockHandle(f *os.File, wait bool) error {
+	how := syscall.LOCK_EX
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return errLocked
+		}
+		if !errors.Is(err, syscall.EINTR) {
+			return err
+		}
+	}
+}
+
+func unlockHandle(f *os.File) {
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 
+	"github.com/charmbracelet/x/term"
 	"github.com/spf13/cobra"
 )
 
@@ -15,19 +20,108 @@ import (
 var grabCmd = &cobra.Command{
 	Use:   "grab [URL]",
 	Short: "Download videos, images, or files from the internet",
-	Args:  cobra.ExactArgs(1),
+	Long: `Download videos, images, or files from the internet.
+
+Several files can be downloaded at once from a list with -i (one URL per line,
+"-" for stdin), or by piping URLs in. URLs that fail are saved to a file that
+can be retried with -i.
+
+Downloads can also be queued with "grab add" and run in the background by
+"grab daemon".`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		if grabWith != "" && grabWith != "wget" && grabWith != "curl" {
+			fmt.Println("❌ Unsupported downloader! Use wget or curl.")
+			return
+		}
+		verify, err := grabVerifyFromFlags()
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		if verify != nil && grabWith != "" {
+			fmt.Println("❌ Checksums and signatures can only be checked by the built-in downloader.")
+			return
+		}
+
+		// A list of URLs from -i, or piped in when no URL is given
+		if grabInput == "" && len(args) == 0 && !term.IsTerminal(os.Stdin.Fd()) {
+			grabInput = "-"
+		}
+		if grabInput != "" {
+			if len(args) > 0 || grabOutput != "" || grabWith != "" {
+				fmt.Println("❌ -i can't be combined with a URL, --output or --with.")
+				return
+			}
+			if verify != nil && (len(verify.Sums) > 0 || verify.Signature != "") {
+				fmt.Println("❌ --sha256, --sha512, --md5 and --signature are for a single file. Use --checksum-url or --minisign-key with -i.")
+				return
+			}
+			urls, err := readURLList(grabInput)
+			if err != nil {
+				fmt.Println("❌ Could not read the URL list:", err)
+				return
+			}
+			downloadBatch(urls, verify)
+			return
+		}
+		if len(args) == 0 {
+			fmt.Println("❌ Give a URL to download, or a list of them with -i.")
+			return
+		}
 		url := args[0]
+		if verify != nil && isVideoPlatform(url) {
+			fmt.Println("❌ Checksums and signatures can't be checked for videos, which are downloaded with yt-dlp.")
+			return
+		}
 		downloadFile(url)
 	},
 }
 
+// Grab flags
+var grabOutput string
+var grabDir string
+var grabWith string
+var grabConnections int
+
+// Verification flags
+var (
+	grabSHA256      string
+	grabSHA512      string
+	grabMD5         string
+	grabChecksumURL string
+	grabSignature   string
+	grabMinisignKey string
+	grabQuarantine  bool
+)
+
+// Build the verification options from the flags (nil if none are set)
+func grabVerifyFromFlags() (*grabVerify, error) {
+	sums := map[string]string{}
+	for algo, sum := range map[string]string{"sha256": grabSHA256, "sha512": grabSHA512, "md5": grabMD5} {
+		if sum != "" {
+			sums[algo] = strings.ToLower(strings.TrimSpace(sum))
+		}
+	}
+	if len(sums) == 0 && grabChecksumURL == "" && grabSignature == "" && grabMinisignKey == "" {
+		return nil, nil
+	}
+	v := &grabVerify{
+		Sums:       sums,
+		SumsURL:    grabChecksumURL,
+		Signature:  grabSignature,
+		PublicKey:  grabMinisignKey,
+		Quarantine: grabQuarantine,
+	}
+	return v, v.validate()
+}
+
 // Detect file type and download appropriately
 func downloadFile(url string) {
 	fmt.Println("🔍 Detecting file type...")
 
 	// Check if URL is a YouTube link (or TikTok)
-	if strings.Contains(url, "youtube.com") || strings.Contains(url, "youtu.be") || strings.Contains(url, "tiktok.com") {
+	if isVideoPlatform(url) {
 		fmt.Println("🎥 Detected Video Platform! Using yt-dlp...")
 		downloadWithYTDLP(url)
 		return
@@ -37,32 +131,99 @@ func downloadFile(url string) {
 	ext := filepath.Ext(url)
 	if ext != "" {
 		fmt.Printf("📂 Detected File Download! File Type: %s\n", ext)
-		downloadWithWget(url)
+		downloadDirect(url)
 		return
 	}
 
 	// If it's a webpage, save it as an offline page
 	if isWebPage(url) {
 		fmt.Println("🌍 Detected Webpage! Saving for offline use...")
-		downloadWithWget(url)
+		downloadDirect(url)
 		return
 	}
 
 	// If no match, just try to download it
 	fmt.Println("📡 Unknown type. Attempting to download...")
-	downloadWithCurl(url)
+	downloadDirect(url)
+}
+
+// Videos go through yt-dlp, which grab can't check the output of
+var errVideoVerify = errors.New("checksums and signatures can't be checked for videos downloaded with yt-dlp")
+
+// Whether a URL is on a video site that needs yt-dlp
+func isVideoPlatform(url string) bool {
+	return strings.Contains(url, "youtube.com") || strings.Contains(url, "youtu.be") || strings.Contains(url, "tiktok.com")
+}
+
+// Download a file with the built-in downloader, or wget/curl when asked to
+func downloadDirect(url string) {
+	switch grabWith {
+	case "wget":
+		downloadWithWget(url)
+		return
+	case "curl":
+		downloadWithCurl(url)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	g := newGrabber()
+	g.connections = grabConnections
+	verify, _ := grabVerifyFromFlags()
+	path, err := g.download(ctx, grabRequest{URL: url, Dir: grabDir, Output: grabOutput, Verify: verify})
+	if ctx.Err() != nil {
+		fmt.Println("\n⏸️ Download interrupted. Run the same command again to resume.")
+		return
+	}
+	if err != nil {
+		fmt.Println("❌ Failed to download file:", err)
+		return
+	}
+	if verify != nil {
+		fmt.Println("🔒 Verified:", strings.Join(verify.Passed, ", "))
+		if verify.TrustedComment != "" {
+			fmt.Println("🔏 Signed:", verify.TrustedComment)
+		}
+	}
+	fmt.Println("✅ Download complete! Saved as", path)
+}
+
+// Directory yt-dlp saves videos in. exec doesn't expand "~", so the home
+// directory is looked up here.
+func videoDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Downloads"), nil
+}
+
+// Build the yt-dlp command that downloads a video into videoDir
+func ytdlpCommand(ctx context.Context, url string) (*exec.Cmd, string, error) {
+	dir, err := videoDir()
+	if err != nil {
+		return nil, "", err
+	}
+	output := filepath.Join(dir, "%(title)s.%(ext)s")
+	return exec.CommandContext(ctx, "yt-dlp", "-f", "bestvideo[ext=mp4]+bestaudio[ext=m4a]/best[ext=mp4]", "-o", output, url), dir, nil
 }
 
 // Uses yt-dlp for video downloads
 func downloadWithYTDLP(url string) {
-	cmd := exec.Command("yt-dlp", "-f", "bestvideo[ext=mp4]+bestaudio[ext=m4a]/best[ext=mp4]", "-o", "~/Downloads/%(title)s.%(ext)s", url)
+	cmd, dir, err := ytdlpCommand(context.Background(), url)
+	if err != nil {
+		fmt.Println("❌ Failed to download video:", err)
+		return
+	}
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
 		fmt.Println("❌ Failed to download video:", err)
 	} else {
-		fmt.Println("✅ Download complete! Saved in ~/Downloads")
+		fmt.Println("✅ Download complete! Saved in", dir)
 	}
 }
 
@@ -92,6 +253,12 @@ func downloadWithCurl(url string) {
 	}
 }
 
+// Download a URL into a file, resuming a partial download if there is one
+func downloadToFile(url, path string) error {
+	_, err := newGrabber().download(context.Background(), grabRequest{URL: url, Output: path})
+	return err
+}
+
 // Simple check if it's a webpage (not a direct file)
 func isWebPage(url string) bool {
 	resp, err := http.Head(url)
@@ -104,4 +271,19 @@ func isWebPage(url string) bool {
 
 func init() {
 	rootCmd.AddCommand(grabCmd)
+	grabCmd.Flags().StringVarP(&grabOutput, "output", "o", "", "Save to this file instead of the server's file name")
+	grabCmd.Flags().StringVarP(&grabDir, "dir", "d", ".", "Directory to save files in")
+	grabCmd.Flags().IntVarP(&grabConnections, "connections", "c", 1, "Download large files over this many connections at once")
+	grabCmd.Flags().StringVarP(&grabInput, "input", "i", "", "Download every URL in this file (\"-\" for stdin)")
+	grabCmd.Flags().IntVarP(&grabJobs, "jobs", "j", 4, "Downloads to run at once with -i")
+	grabCmd.Flags().IntVar(&grabPerHost, "per-host", 2, "Connections to open at once to the same host with -i, counting segments and redirects")
+	grabCmd.Flags().StringVar(&grabFailedFile, "failed", "failed.txt", "Where -i saves the URLs that failed")
+	grabCmd.Flags().StringVar(&grabSHA256, "sha256", "", "Expected SHA-256 checksum (hex)")
+	grabCmd.Flags().StringVar(&grabSHA512, "sha512", "", "Expected SHA-512 checksum (hex)")
+	grabCmd.Flags().StringVar(&grabMD5, "md5", "", "Expected MD5 checksum (hex)")
+	grabCmd.Flags().StringVar(&grabChecksumURL, "checksum-url", "", "Checksum list (e.g. SHA256SUMS) to look the file up in")
+	grabCmd.Flags().StringVar(&grabMinisignKey, "minisign-key", "", "Verify a minisign signature with this public key (or key file)")
+	grabCmd.Flags().StringVar(&grabSignature, "signature", "", "Signature file or URL (default: <URL>.minisig)")
+	grabCmd.Flags().BoolVar(&grabQuarantine, "quarantine", false, "Keep files that fail verification as <name>.quarantine instead of deleting them")
+	grabCmd.Flags().StringVar(&grabWith, "with", "", "Use an external downloader instead (wget, curl)")
 }
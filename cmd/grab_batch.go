@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Batch flags
+var grabInput string
+var grabJobs int
+var grabPerHost int
+var grabFailedFile string
+
+// One URL of a batch and how its download went
+type batchJob struct {
+	URL  string
+	Host string
+	Path string
+	Err  error
+}
+
+// Read a URL list ("-" for stdin): one URL per line, blank lines and
+// # comments are skipped, and duplicates are dropped
+func readURLList(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var urls []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || seen[line] {
+			continue
+		}
+		seen[line] = true
+		urls = append(urls, line)
+	}
+	return urls, scanner.Err()
+}
+
+// Concurrent download queue: a fixed number of workers, which only start a
+// job while its host has fewer than perHost downloads running. The grabber's
+// host slots hold the connections themselves to the limit, counting segments
+// and the hosts downloads are redirected to.
+type batchQueue struct {
+	g       *grabber
+	verify  *grabVerify
+	dir     string
+	perHost int
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*batchJob
+	active  map[string]int
+}
+
+func newBatchQueue(g *grabber, urls []string, perHost int) *batchQueue {
+	q := &batchQueue{g: g, perHost: max(1, perHost), active: map[string]int{}}
+	q.cond = sync.NewCond(&q.mu)
+	for _, u := range urls {
+		job := &batchJob{URL: u}
+		if parsed, err := url.Parse(u); err == nil {
+			job.Host = parsed.Host
+		}
+		q.pending = append(q.pending, job)
+	}
+	return q
+}
+
+// Take the next job whose host has a free slot, waiting for one if needed.
+// Returns nil once the queue is empty.
+func (q *batchQueue) next() *batchJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.pending) > 0 {
+		for i, job := range q.pending {
+			if q.active[job.Host] < q.perHost {
+				q.pending = slices.Delete(q.pending, i, i+1)
+				q.active[job.Host]++
+				return job
+			}
+		}
+		q.cond.Wait()
+	}
+	return nil
+}
+
+// Free a job's host slot
+func (q *batchQueue) done(job *batchJob) {
+	q.mu.Lock()
+	q.active[job.Host]--
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// Give up on the jobs that haven't started (after an interrupt)
+func (q *batchQueue) drain(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.pending {
+		job.Err = err
+	}
+	q.pending = nil
+	q.cond.Broadcast()
+}
+
+// Download every job with the given number of workers. Results are in list order.
+func (q *batchQueue) run(ctx context.Context, workers int, progress *batchProgress) []*batchJob {
+	jobs := slices.Clone(q.pending)
+	var wg sync.WaitGroup
+	for w := 0; w < max(1, min(workers, len(jobs))); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := q.next(); job != nil; job = q.next() {
+				if ctx.Err() != nil {
+					job.Err = ctx.Err()
+				} else {
+					job.Path, job.Err = q.fetch(ctx, job)
+				}
+				q.done(job)
+				progress.report(job)
+			}
+		}()
+	}
+
+	// An interrupt stops the workers from picking up anything new
+	go func() {
+		<-ctx.Done()
+		q.drain(ctx.Err())
+	}()
+	wg.Wait()
+	return jobs
+}
+
+// Download one job: video platforms through yt-dlp, everything else natively
+func (q *batchQueue) fetch(ctx context.Context, job *batchJob) (string, error) {
+	if isVideoPlatform(job.URL) {
+		if q.verify != nil {
+			return "", errVideoVerify
+		}
+		cmd, dir, err := ytdlpCommand(ctx, job.URL)
+		if err != nil {
+			return "", err
+		}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+			return "", fmt.Errorf("yt-dlp: %v: %s", err, lines[len(lines)-1])
+		}
+		return dir, nil
+	}
+
+	var verify *grabVerify
+	if q.verify != nil {
+		// Each file gets its own copy since the results are written into it
+		v := *q.verify
+		verify = &v
+	}
+	return q.g.download(ctx, grabRequest{URL: job.URL, Dir: q.dir, Verify: verify})
+}
+
+// One status line for a whole batch: how many files are done, the combined
+// speed and the files in flight. Results are printed above it as they come in.
+type batchProgress struct {
+	total  int
+	draw   bool
+	start  time.Time
+	bytes  atomic.Int64
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	active []*progressBar
+	ok     int
+	failed int
+}
+
+// Start the display; the status line is only drawn when draw is set
+func newBatchProgress(total int, draw bool) *batchProgress {
+	b := &batchProgress{total: total, draw: draw, start: time.Now(), stop: make(chan struct{})}
+	if draw {
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			ticker := time.NewTicker(200 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-b.stop:
+					return
+				case <-ticker.C:
+					b.mu.Lock()
+					b.render()
+					b.mu.Unlock()
+				}
+			}
+		}()
+	}
+	return b
+}
+
+// Bar for one download of the batch; drawn as part of the status line
+func (b *batchProgress) track(dest string, total, done int64) *progressBar {
+	p := &progressBar{name: filepath.Base(dest), total: total, resumed: done, start: time.Now(), tracker: b}
+	p.done.Store(done)
+	b.mu.Lock()
+	b.active = append(b.active, p)
+	b.mu.Unlock()
+	return p
+}
+
+func (b *batchProgress) add(n int64) {
+	b.bytes.Add(n)
+}
+
+func (b *batchProgress) untrack(p *progressBar) {
+	b.mu.Lock()
+	b.active = slices.DeleteFunc(b.active, func(a *progressBar) bool { return a == p })
+	b.mu.Unlock()
+}
+
+// Print the outcome of a job above the status line
+func (b *batchProgress) report(job *batchJob) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clear()
+	switch {
+	case errors.Is(job.Err, context.Canceled):
+		return
+	case job.Err != nil:
+		b.failed++
+		fmt.Printf("❌ %s: %s\n", job.URL, downloadErrorText(job.Err))
+	default:
+		b.ok++
+		fmt.Printf("✅ %s → %s\n", job.URL, job.Path)
+	}
+	b.render()
+}
+
+// Stop drawing and remove the status line
+func (b *batchProgress) finish() {
+	if !b.draw {
+		return
+	}
+	close(b.stop)
+	b.wg.Wait()
+	b.mu.Lock()
+	b.clear()
+	b.mu.Unlock()
+}
+
+func (b *batchProgress) clear() {
+	if b.draw {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+}
+
+// Redraw the status line (callers hold b.mu)
+func (b *batchProgress) render() {
+	if !b.draw {
+		return
+	}
+	elapsed := time.Since(b.start).Seconds()
+	speed := 0.0
+	if elapsed > 0 {
+		speed = float64(b.bytes.Load()) / elapsed
+	}
+	line := fmt.Sprintf("⬇️  [%d/%d", b.ok+b.failed, b.total)
+	if b.failed > 0 {
+		line += fmt.Sprintf(", %d failed", b.failed)
+	}
+	line += fmt.Sprintf("] %s %s/s", formatBytes(b.bytes.Load()), formatBytes(int64(speed)))
+	for _, p := range b.active {
+		line += " · " + p.name
+		if p.total > 0 {
+			line += fmt.Sprintf(" %.0f%%", float64(p.done.Load())/float64(p.total)*100)
+		}
+	}
+	width, _ := terminalSize()
+	fmt.Fprint(os.Stderr, "\r"+runewidth.Truncate(line, width-1, "…")+"\033[K")
+}
+
+// Download a list of URLs concurrently, then report what failed and write
+// those URLs to a file that can be fed back to `grab -i`
+func downloadBatch(urls []string, verify *grabVerify) {
+	if len(urls) == 0 {
+		fmt.Println("⚠️ No URLs to download.")
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	g := newGrabber()
+	g.connections = grabConnections
+	g.hosts = newHostSlots(grabPerHost)
+	progress := newBatchProgress(len(urls), g.progress)
+	g.tracker = progress
+
+	q := newBatchQueue(g, urls, grabPerHost)
+	q.verify = verify
+	q.dir = grabDir
+	fmt.Printf("📡 Downloading %s (%d at a time, %d connections per host)...\n", plural(len(urls), "file"), grabJobs, q.perHost)
+	start := time.Now()
+	results := q.run(ctx, grabJobs, progress)
+	progress.finish()
+
+	var failed []*batchJob
+	interrupted := 0
+	for _, job := range results {
+		if job.Err != nil {
+			failed = append(failed, job)
+		}
+		if errors.Is(job.Err, context.Canceled) {
+			interrupted++
+		}
+	}
+	fmt.Printf("\n📊 %d downloaded, %d failed", len(results)-len(failed), len(failed)-interrupted)
+	if interrupted > 0 {
+		fmt.Printf(", %d interrupted", interrupted)
+	}
+	fmt.Printf(" in %s (%s)\n", time.Since(start).Round(time.Second), formatBytes(progress.bytes.Load()))
+	if interrupted > 0 {
+		fmt.Println("⏸️ Interrupted. Unfinished downloads resume when retried.")
+	}
+
+	if len(failed) == 0 {
+		// A retry list that fully succeeded is done with
+		if grabInput == grabFailedFile {
+			os.Remove(grabFailedFile)
+		}
+		return
+	}
+	var list strings.Builder
+	for _, job := range failed {
+		list.WriteString(job.URL + "\n")
+	}
+	if err := writeFileAtomic(grabFailedFile, []byte(list.String()), 0644); err != nil {
+		fmt.Println("❌ Could not write the URLs to retry:", err)
+	} else {
+		fmt.Printf("📝 URLs to retry saved to %s. Retry with: %s grab -i %s\n", grabFailedFile, rootCmd.Name(), grabFailedFile)
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadURLList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.txt")
+	os.WriteFile(path, []byte("# files\nhttps://a.example/1\n\n  https://a.example/2  \nhttps://a.example/1\n"), 0644)
+	urls, err := readURLList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://a.example/1", "https://a.example/2"}; !slices.Equal(urls, want) {
+		t.Errorf("got %q, want %q", urls, want)
+	}
+}
+
+// Reader that trickles so downloads overlap long enough to be counted
+type slowReader struct {
+	*bytes.Reader
+}
+
+func (r slowReader) Read(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return r.Reader.Read(p[:min(len(p), 32*1024)])
+}
+
+func newSlowFileServer(t *testing.T, content []byte) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "file.bin", time.Time{}, slowReader{bytes.NewReader(content)})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Transport that records the most responses it had open to one host at once
+type countingTransport struct {
+	http.RoundTripper
+	host   string
+	active atomic.Int32
+	peak   atomic.Int32
+}
+
+type countedBody struct {
+	io.ReadCloser
+	t    *countingTransport
+	once sync.Once
+}
+
+func (b *countedBody) Close() error {
+	b.once.Do(func() { b.t.active.Add(-1) })
+	return b.ReadCloser.Close()
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.RoundTripper.RoundTrip(req)
+	}
+	n := t.active.Add(1)
+	for p := t.peak.Load(); n > p && !t.peak.CompareAndSwap(p, n); p = t.peak.Load() {
+	}
+	resp, err := t.RoundTripper.RoundTrip(req)
+	if err != nil {
+		t.active.Add(-1)
+		return nil, err
+	}
+	resp.Body = &countedBody{ReadCloser: resp.Body, t: t}
+	return resp, nil
+}
+
+// Download urls as a batch and return the peak connections to host
+func runTestBatch(t *testing.T, urls []string, host string, perHost, connections int) int32 {
+	g := newTestGrabber()
+	counter := &countingTransport{RoundTripper: g.client.Transport, host: host}
+	g.client.Transport = counter
+	g.connections = connections
+	g.hosts = newHostSlots(perHost)
+	q := newBatchQueue(g, urls, perHost)
+	q.dir = t.TempDir()
+	jobs := q.run(context.Background(), 4, newBatchProgress(len(urls), false))
+	for _, job := range jobs {
+		if job.Err != nil {
+			t.Errorf("%s: %v", job.URL, job.Err)
+		}
+	}
+	return counter.peak.Load()
+}
+
+func TestBatchPerHostCountsSegments(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 2*minSegmentedSize)
+	srv := newSlowFileServer(t, content)
+
+	urls := []string{srv.URL + "/a.bin", srv.URL + "/b.bin"}
+	if p := runTestBatch(t, urls, srv.Listener.Addr().String(), 2, 4); p > 2 {
+		t.Errorf("%d connections to one host at once, limit 2", p)
+	}
+}
+
+func TestBatchPerHostFollowsRedirects(t *testing.T) {
+	files := newSlowFileServer(t, bytes.Repeat([]byte("x"), 256*1024))
+	var urls []string
+	for i := range 3 {
+		// Separate hosts that all send their downloads to the same file server
+		origin := httptest.NewServer(http.RedirectHandler(files.URL+fmt.Sprintf("/file%d.bin", i), http.StatusFound))
+		t.Cleanup(origin.Close)
+		urls = append(urls, origin.URL+"/download")
+	}
+
+	if p := runTestBatch(t, urls, files.Listener.Addr().String(), 1, 1); p > 1 {
+		t.Errorf("%d connections to the redirect target at once, limit 1", p)
+	}
+}
+
+func TestYTDLPCommandUsesHomeDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	cmd, dir, err := ytdlpCommand(context.Background(), "https://youtu.be/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != filepath.Join(home, "Downloads") {
+		t.Errorf("dir = %q", dir)
+	}
+	if !slices.Contains(cmd.Args, filepath.Join(home, "Downloads", "%(title)s.%(ext)s")) {
+		t.Errorf("output template not under the home directory: %q", cmd.Args)
+	}
+}
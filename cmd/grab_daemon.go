@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Daemon flags
+var grabDaemonJobs int
+
+// Runs the download queue and answers requests on the socket
+type grabDaemon struct {
+	mu      sync.Mutex
+	queue   *grabQueue
+	base    *grabber
+	workers int
+	running map[int]*runningJob
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// A download in progress
+type runningJob struct {
+	cancel context.CancelFunc
+	bar    *progressBar
+}
+
+// Reports a job's progress back to the daemon
+type jobTracker struct {
+	d  *grabDaemon
+	id int
+}
+
+func (t *jobTracker) track(dest string, total, done int64) *progressBar {
+	p := &progressBar{total: total, resumed: done, start: time.Now(), tracker: t}
+	p.done.Store(done)
+	t.d.mu.Lock()
+	defer t.d.mu.Unlock()
+	if job := t.d.queue.job(t.id); job != nil {
+		job.Path, job.Size, job.Done = dest, total, done
+	}
+	if r := t.d.running[t.id]; r != nil {
+		r.bar = p
+	}
+	// Save now so a restart knows where the partial file is
+	t.d.save()
+	return p
+}
+
+func (t *jobTracker) untrack(p *progressBar) {
+	t.d.mu.Lock()
+	defer t.d.mu.Unlock()
+	if job := t.d.queue.job(t.id); job != nil {
+		job.Done = p.done.Load()
+	}
+	if r := t.d.running[t.id]; r != nil {
+		r.bar = nil
+	}
+}
+
+func (t *jobTracker) add(int64) {}
+
+// Save the queue (callers hold d.mu)
+func (d *grabDaemon) save() {
+	if err := d.queue.save(); err != nil {
+		fmt.Println("⚠️ Could not save the queue:", err)
+	}
+}
+
+// Start queued downloads while there are free workers (callers hold d.mu)
+func (d *grabDaemon) schedule() {
+	if d.stopped {
+		return
+	}
+	for _, job := range d.queue.Jobs {
+		if len(d.running) >= d.workers {
+			return
+		}
+		// A job resumed right after a pause may still be winding down
+		if job.State == jobQueued && d.running[job.ID] == nil {
+			d.start(job)
+		}
+	}
+}
+
+// Run one download in the background (callers hold d.mu)
+func (d *grabDaemon) start(job *queueJob) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.running[job.ID] = &runningJob{cancel: cancel}
+	job.State, job.Error = jobRunning, ""
+	d.save()
+	fmt.Printf("⬇️  #%d %s\n", job.ID, job.URL)
+
+	g := *d.base
+	g.tracker = &jobTracker{d: d, id: job.ID}
+	if job.Connections > 0 {
+		g.connections = job.Connections
+	}
+	req := grabRequest{URL: job.URL, Dir: job.Dir, Output: job.Output}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		path, err := g.download(ctx, req)
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.running, job.ID)
+		switch {
+		case job.State == jobPaused:
+			fmt.Printf("⏸️ #%d paused\n", job.ID)
+		case job.State == jobCanceled:
+			removePartial(job)
+			fmt.Printf("🚫 #%d canceled\n", job.ID)
+		case d.stopped || ctx.Err() != nil:
+			// Stopped by a shutdown, or paused and resumed again before it wound
+			// down: the download continues from its partial file later
+			job.State = jobQueued
+		case err != nil:
+			job.State, job.Error, job.Finished = jobFailed, downloadErrorText(err), time.Now()
+			fmt.Printf("❌ #%d failed: %v\n", job.ID, err)
+		default:
+			job.State, job.Path, job.Finished = jobDone, path, time.Now()
+			if info, err := os.Stat(path); err == nil {
+				job.Size, job.Done = info.Size(), info.Size()
+			}
+			fmt.Printf("✅ #%d saved as %s\n", job.ID, path)
+		}
+		cancel()
+		d.save()
+		d.schedule()
+	}()
+}
+
+// Answer a request from a client
+func (d *grabDaemon) handle(req queueRequest) queueResponse {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	resp := d.queue.handle(req)
+	if resp.Error != "" {
+		return resp
+	}
+
+	switch req.Op {
+	case "status":
+		// Report live progress without touching the stored jobs
+		jobs := make([]*queueJob, len(resp.Jobs))
+		for i, job := range resp.Jobs {
+			copied := *job
+			if r := d.running[job.ID]; r != nil && r.bar != nil {
+				copied.Done = r.bar.done.Load()
+			}
+			jobs[i] = &copied
+		}
+		return queueResponse{Jobs: jobs}
+	case "pause", "cancel":
+		job := resp.Jobs[0]
+		if r := d.running[job.ID]; r != nil {
+			r.cancel()
+		} else if job.State == jobCanceled {
+			removePartial(job)
+		}
+	}
+	d.save()
+	d.schedule()
+	return resp
+}
+
+// Serve one client connection: a request and its answer
+func (d *grabDaemon) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	var req queueRequest
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(queueResponse{Error: "invalid request"})
+		return
+	}
+	json.NewEncoder(conn).Encode(d.handle(req))
+}
+
+// Stop all downloads, leaving them queued with their partial files for next time
+func (d *grabDaemon) shutdown() {
+	d.mu.Lock()
+	d.stopped = true
+	for _, r := range d.running {
+		r.cancel()
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.save()
+}
+
+// Delete the partial file of a canceled download
+func removePartial(job *queueJob) {
+	if job.Path == "" {
+		return
+	}
+	os.Remove(job.Path + partSuffix)
+	os.Remove(job.Path + partSuffix + segmentStateSuffix)
+	os.Remove(job.Path + partSuffix + partValidatorSuffix)
+}
+
+// Listen on the daemon socket, replacing a stale one left by a crash
+func listenGrabSocket() (net.Listener, error) {
+	path := grabSocketPath()
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, errors.New("the daemon is already running")
+	}
+	os.Remove(path)
+	if err := os.MkdirAll(brightsideDataDir(), 0755); err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Only the owner may control the downloads
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// grabDaemonCmd processes the download queue until interrupted
+var grabDaemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Process the download queue in the background",
+	Long: `Process the download queue. Downloads added with "grab add" start as workers
+become free and can be controlled with "grab status|pause|resume|cancel".
+
+Stopping the daemon keeps unfinished downloads queued; they resume from their
+partial files when it starts again.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Held until exit, so clients only edit the queue file while no daemon runs
+		lock, err := tryLockFile(grabQueuePath(), 2*time.Second)
+		if errors.Is(err, errLocked) {
+			fmt.Println("❌ The daemon is already running.")
+			return
+		}
+		if err != nil {
+			fmt.Println("❌ Failed to lock the download queue:", err)
+			return
+		}
+		defer lock.unlock()
+
+		q, err := loadGrabQueue()
+		if err != nil {
+			fmt.Println("❌ Failed to load the download queue:", err)
+			return
+		}
+		// Downloads that were running when the daemon last stopped
+		for _, job := range q.Jobs {
+			if job.State == jobRunning {
+				job.State = jobQueued
+			}
+		}
+
+		l, err := listenGrabSocket()
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		defer os.Remove(grabSocketPath())
+
+		base := newGrabber()
+		base.progress = false
+		d := &grabDaemon{queue: q, base: base, workers: max(1, grabDaemonJobs), running: map[int]*runningJob{}}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			l.Close()
+		}()
+
+		fmt.Printf("📡 Download daemon running (%d at a time). Press Ctrl+C to stop.\n", d.workers)
+		d.mu.Lock()
+		d.save()
+		d.schedule()
+		d.mu.Unlock()
+
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				break
+			}
+			go d.serve(conn)
+		}
+
+		fmt.Println("\n⏸️ Stopping. Unfinished downloads resume next time.")
+		d.shutdown()
+	},
+}
+
+func init() {
+	grabCmd.AddCommand(grabDaemonCmd)
+	grabDaemonCmd.Flags().IntVarP(&grabDaemonJobs, "jobs", "j", 2, "Downloads to run at once")
+}
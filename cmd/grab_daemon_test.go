@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Pausing and resuming a download before it has wound down must neither start
+// it twice nor lose it: it is requeued and continues from its partial file
+func TestDaemonPauseResumeWhileWindingDown(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	content := bytes.Repeat([]byte("0123456789"), 100*1024)
+	var ranged atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			ranged.Add(1)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "file.bin", time.Time{}, slowReader{bytes.NewReader(content)})
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	d := &grabDaemon{queue: &grabQueue{NextID: 1}, base: newTestGrabber(), workers: 1, running: map[int]*runningJob{}}
+	if resp := d.handle(queueRequest{Op: "add", URL: srv.URL + "/file.bin", Dir: dir}); resp.Error != "" {
+		t.Fatal(resp.Error)
+	}
+
+	for range 5 {
+		time.Sleep(5 * time.Millisecond)
+		if resp := d.handle(queueRequest{Op: "pause", ID: 1}); resp.Error != "" {
+			t.Fatal(resp.Error)
+		}
+		if resp := d.handle(queueRequest{Op: "resume", ID: 1}); resp.Error != "" {
+			t.Fatal(resp.Error)
+		}
+		d.mu.Lock()
+		if len(d.running) > 1 {
+			t.Errorf("%d downloads running for one job", len(d.running))
+		}
+		d.mu.Unlock()
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		d.mu.Lock()
+		job := *d.queue.Jobs[0]
+		d.mu.Unlock()
+		if job.State == jobDone {
+			break
+		}
+		if job.State == jobFailed || time.Now().After(deadline) {
+			t.Fatalf("download ended up %s: %s", job.State, job.Error)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	d.wg.Wait()
+
+	got, err := os.ReadFile(filepath.Join(dir, "file.bin"))
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("downloaded file differs (err %v)", err)
+	}
+	if ranged.Load() == 0 {
+		t.Error("the resumed download started over instead of continuing")
+	}
+}
@@ -0,0 +1,692 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/mattn/go-runewidth"
+)
+
+// Suffix of files that are still being downloaded
+const partSuffix = ".part"
+
+// Suffix of the file holding the ETag or Last-Modified of the version of the
+// file a partial download started from, sent with If-Range when it resumes
+const partValidatorSuffix = ".validator"
+
+// User agent sent by grab
+const grabUserAgent = "brightside-go/1.0 (+https://github.com/br1ghts/brightside-go)"
+
+// How long grab waits to connect, for the TLS handshake and for response headers
+const (
+	grabDialTimeout   = 30 * time.Second
+	grabTLSTimeout    = 15 * time.Second
+	grabHeaderTimeout = 30 * time.Second
+)
+
+// A response that sends no data for this long is abandoned
+const grabIdleTimeout = time.Minute
+
+// Non-2xx response from the server
+type httpStatusError struct {
+	URL    string
+	Status string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("%s: %s", e.URL, e.Status)
+}
+
+// Error text for a download listed next to its URL: without the URL again
+func downloadErrorText(err error) string {
+	var serr *httpStatusError
+	if errors.As(err, &serr) {
+		return serr.Status
+	}
+	return err.Error()
+}
+
+// What to download and where to put it
+type grabRequest struct {
+	URL    string
+	Dir    string // directory for the file, named after the response
+	Output string // exact destination path (overrides Dir)
+	Verify *grabVerify
+}
+
+// Downloads files over HTTP with resume, redirects and progress
+type grabber struct {
+	client      *http.Client
+	progress    bool
+	connections int
+	idleTimeout time.Duration
+
+	// When set, downloads report here instead of drawing their own bars
+	tracker progressTracker
+
+	// Shared by copies of the grabber so concurrent downloads never use the same file
+	claims *destClaims
+
+	// Limits the connections to each host when set, shared like claims
+	hosts *hostSlots
+}
+
+// Receives the progress of downloads that don't draw their own bar
+type progressTracker interface {
+	track(dest string, total, done int64) *progressBar
+	untrack(p *progressBar)
+	add(n int64)
+}
+
+// Destinations of downloads in flight
+type destClaims struct {
+	mu      sync.Mutex
+	claimed map[string]bool
+}
+
+// Connections allowed to each host at once. Every download holds a slot for
+// its host while it transfers, and a segmented one holds one per segment.
+type hostSlots struct {
+	limit int
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newHostSlots(limit int) *hostSlots {
+	return &hostSlots{limit: max(1, limit), slots: map[string]chan struct{}{}}
+}
+
+func (h *hostSlots) slot(host string) chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c, ok := h.slots[host]
+	if !ok {
+		c = make(chan struct{}, h.limit)
+		h.slots[host] = c
+	}
+	return c
+}
+
+// Wait for a free slot for host. A nil hostSlots never limits.
+func (h *hostSlots) acquire(ctx context.Context, host string) error {
+	if h == nil {
+		return nil
+	}
+	select {
+	case h.slot(host) <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *hostSlots) release(host string) {
+	if h != nil {
+		<-h.slot(host)
+	}
+}
+
+// Create a downloader; progress bars are only drawn on a terminal
+func newGrabber() *grabber {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: grabDialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = grabTLSTimeout
+	transport.ResponseHeaderTimeout = grabHeaderTimeout
+	return &grabber{
+		client:      &http.Client{Transport: transport, CheckRedirect: moveHostSlot},
+		progress:    term.IsTerminal(os.Stderr.Fd()),
+		connections: 1,
+		idleTimeout: grabIdleTimeout,
+		claims:      &destClaims{claimed: map[string]bool{}},
+	}
+}
+
+// Progress bar for one download: its own line, one reported to the tracker, or none
+func (g *grabber) startProgress(dest string, total, done int64) *progressBar {
+	if g.tracker != nil {
+		return g.tracker.track(dest, total, done)
+	}
+	if g.progress {
+		return newProgressBar(filepath.Base(dest), total, done)
+	}
+	return nil
+}
+
+// Reserve a destination for a download, picking "name (n).ext" if another
+// download in flight already uses it. Release it with g.release.
+func (g *grabber) claim(dest string) string {
+	c := g.claims
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	candidate := dest
+	ext := filepath.Ext(dest)
+	for n := 1; c.claimed[candidate]; n++ {
+		candidate = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(dest, ext), n, ext)
+	}
+	c.claimed[candidate] = true
+	return candidate
+}
+
+func (g *grabber) release(dest string) {
+	g.claims.mu.Lock()
+	defer g.claims.mu.Unlock()
+	delete(g.claims.claimed, dest)
+}
+
+// Send a GET request, optionally for a byte range
+func (g *grabber) get(ctx context.Context, rawURL string, from int64, validator string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", grabUserAgent)
+	if from > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", from))
+		if validator != "" {
+			req.Header.Set("If-Range", validator)
+		}
+	}
+	resp, err := g.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, &httpStatusError{URL: rawURL, Status: resp.Status}
+	}
+	return resp, nil
+}
+
+// Send a request. Its body fails once no data has arrived for the idle timeout,
+// so a stalled server can't hang a download forever.
+func (g *grabber) do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	resp, err := g.client.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if g.idleTimeout > 0 {
+		resp.Body = newIdleReader(resp.Body, g.idleTimeout, cancel)
+	} else {
+		resp.Body = cancelOnClose{resp.Body, cancel}
+	}
+	return resp, nil
+}
+
+// Response body that cancels its request when no data arrives for a while
+type idleReader struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	stalled atomic.Bool
+}
+
+func newIdleReader(body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) *idleReader {
+	r := &idleReader{body: body, timeout: timeout, cancel: cancel}
+	r.timer = time.AfterFunc(timeout, func() {
+		r.stalled.Store(true)
+		cancel()
+	})
+	return r
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	if err != nil && err != io.EOF && r.stalled.Load() {
+		err = fmt.Errorf("no data received for %s", r.timeout)
+	}
+	return n, err
+}
+
+func (r *idleReader) Close() error {
+	r.timer.Stop()
+	r.cancel()
+	return r.body.Close()
+}
+
+// Response body that releases its request's context when closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// Fetch a small text file such as a checksum list or signature
+func (g *grabber) fetchSmall(ctx context.Context, rawURL string) ([]byte, error) {
+	resp, err := g.get(ctx, rawURL, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// ETag or Last-Modified, whichever the server sent (for If-Range)
+func validatorOf(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+// Download a file, resuming a previous partial download when the server
+// supports range requests. The data goes to <dest>.part and is renamed into
+// place once complete and, if asked to, verified. Returns the final path.
+func (g *grabber) download(ctx context.Context, r grabRequest) (string, error) {
+	resp, release, err := g.open(ctx, r.URL)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		resp.Body.Close()
+		release()
+	}()
+
+	dest := r.Output
+	if dest == "" {
+		dest = filepath.Join(r.Dir, filenameFrom(resp))
+	}
+	dest = g.claim(dest)
+	defer g.release(dest)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	part := dest + partSuffix
+	check, err := r.Verify.prepare(ctx, g, r.URL, dest)
+	if err != nil {
+		return "", err
+	}
+
+	// Large files from servers that support ranges can come in several parts at once,
+	// unless an earlier single-stream attempt left a partial file
+	if g.connections > 1 && resp.Header.Get("Accept-Ranges") == "bytes" && resp.ContentLength >= minSegmentedSize &&
+		(!fileExists(part) || hasSegmentState(part)) {
+		// The segments take their own slots for the host
+		resp.Body.Close()
+		release()
+		release = func() {}
+		err := g.downloadSegments(ctx, resp.Request.URL.String(), part, resp.ContentLength, validatorOf(resp), dest)
+		if err != nil {
+			return "", err
+		}
+		return g.finish(r, dest, check)
+	}
+	if hasSegmentState(part) {
+		// The segmented attempt can't be continued in one stream
+		os.Remove(part + segmentStateSuffix)
+		os.Remove(part)
+	}
+
+	// Resume: ask for the rest of the file instead of the whole body. If-Range
+	// carries the validator the partial file started with, so a file that
+	// changed on the server since comes back whole instead.
+	var offset int64
+	stored := readPartValidator(part)
+	info, err := os.Stat(part)
+	if err == nil && info.Size() > 0 && info.Size() == resp.ContentLength {
+		// Finished last time but never renamed. Unless the server confirms the
+		// file hasn't changed since, it is downloaded again from the start.
+		unchanged, err := g.unchanged(ctx, resp, stored, info.Size())
+		if err != nil {
+			return "", err
+		}
+		if unchanged {
+			return g.finish(r, dest, check)
+		}
+	}
+	if err == nil && info.Size() > 0 && stored != "" &&
+		resp.Header.Get("Accept-Ranges") == "bytes" && (resp.ContentLength < 0 || info.Size() < resp.ContentLength) {
+		resp.Body.Close()
+		ranged, err := g.get(ctx, resp.Request.URL.String(), info.Size(), stored)
+		if err != nil {
+			return "", err
+		}
+		resp = ranged
+		// A 200 instead of a 206 means the file changed on the server: start over
+		if resp.StatusCode == http.StatusPartialContent {
+			offset = info.Size()
+		}
+	}
+	if offset == 0 {
+		if err := writePartValidator(part, validatorOf(resp)); err != nil {
+			return "", err
+		}
+	}
+	total := resp.ContentLength
+	if total >= 0 {
+		total += offset
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		// The resumed bytes never went through the hashes
+		if err := check.catchUp(part); err != nil {
+			return "", err
+		}
+	}
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	bar := g.startProgress(dest, total, offset)
+	if bar != nil {
+		defer bar.finish()
+	}
+	written, err := io.Copy(check.writer(f), withProgress(resp.Body, bar))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	if resp.ContentLength >= 0 && written != resp.ContentLength {
+		return "", fmt.Errorf("incomplete download: got %s of %s", formatBytes(offset+written), formatBytes(total))
+	}
+	return g.finish(r, dest, check)
+}
+
+// Start a download with a GET, holding a slot for the host it is on. The slot
+// follows redirects to other hosts. Returns the response and a function that
+// frees the slot.
+func (g *grabber) open(ctx context.Context, rawURL string) (*http.Response, func(), error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := g.hosts.acquire(ctx, u.Host); err != nil {
+		return nil, nil, err
+	}
+	held := &heldSlot{hosts: g.hosts, host: u.Host}
+	resp, err := g.get(context.WithValue(ctx, heldSlotKey{}, held), rawURL, 0, "")
+	if err != nil {
+		held.release()
+		return nil, nil, err
+	}
+	return resp, held.release, nil
+}
+
+// The host slot a request holds, kept in its context
+type heldSlotKey struct{}
+
+type heldSlot struct {
+	hosts *hostSlots
+	host  string
+}
+
+func (h *heldSlot) release() {
+	if h.host != "" {
+		h.hosts.release(h.host)
+		h.host = ""
+	}
+}
+
+// CheckRedirect hook that moves the request's host slot to the host it is
+// redirected to, waiting for one there to be free
+func moveHostSlot(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	held, ok := req.Context().Value(heldSlotKey{}).(*heldSlot)
+	if !ok || held.host == req.URL.Host {
+		return nil
+	}
+	held.release()
+	if err := held.hosts.acquire(req.Context(), req.URL.Host); err != nil {
+		return err
+	}
+	held.host = req.URL.Host
+	return nil
+}
+
+// Whether the file behind a response is still the one a complete partial file
+// of the given size and validator came from: its last byte is requested with
+// If-Range, which the server only answers with a 206 if the file is unchanged
+func (g *grabber) unchanged(ctx context.Context, resp *http.Response, validator string, size int64) (bool, error) {
+	if validator == "" || resp.Header.Get("Accept-Ranges") != "bytes" {
+		return false, nil
+	}
+	last, err := g.get(ctx, resp.Request.URL.String(), size-1, validator)
+	if err != nil {
+		return false, err
+	}
+	defer last.Body.Close()
+	return last.StatusCode == http.StatusPartialContent, nil
+}
+
+// Verify a complete partial file and move it into place. A file that fails
+// verification is deleted or quarantined; the error says which.
+func (g *grabber) finish(r grabRequest, dest string, check *verifier) (string, error) {
+	part := dest + partSuffix
+	if err := check.check(part); err != nil {
+		var verr *verifyError
+		if !errors.As(err, &verr) {
+			return "", err
+		}
+		os.Remove(part + partValidatorSuffix)
+		quarantined, rerr := check.reject(part, dest)
+		switch {
+		case rerr != nil:
+			return "", fmt.Errorf("%w (and could not remove the file: %v)", err, rerr)
+		case quarantined != "":
+			return "", fmt.Errorf("%w; file quarantined as %s", err, quarantined)
+		default:
+			return "", fmt.Errorf("%w; file deleted", err)
+		}
+	}
+
+	final := finalPath(r, dest)
+	if err := os.Rename(part, final); err != nil {
+		return "", err
+	}
+	os.Remove(part + partValidatorSuffix)
+	return final, nil
+}
+
+// Validator a partial download started with ("" if unknown)
+func readPartValidator(part string) string {
+	data, err := os.ReadFile(part + partValidatorSuffix)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Remember the validator of a partial download, or forget it if there is none
+func writePartValidator(part, validator string) error {
+	if validator == "" {
+		err := os.Remove(part + partValidatorSuffix)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return writeFileAtomic(part+partValidatorSuffix, []byte(validator), 0644)
+}
+
+// File name for a response: Content-Disposition, then the last path element
+// of the final (post-redirect) URL, then a generic name
+func filenameFrom(resp *http.Response) string {
+	if cd := resp.Header.Get("Content-Disposition"); cd != "" {
+		if _, params, err := mime.ParseMediaType(cd); err == nil {
+			if name := safeFileName(filepath.Base(params["filename"])); name != "" && name != "." {
+				return name
+			}
+		}
+	}
+
+	name := ""
+	if resp.Request != nil && resp.Request.URL != nil {
+		if p, err := url.PathUnescape(path.Base(resp.Request.URL.Path)); err == nil {
+			name = safeFileName(p)
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if name == "" || name == "/" || name == "." {
+		if mediaType == "text/html" {
+			return "index.html"
+		}
+		name = "download"
+	}
+	if path.Ext(name) == "" && mediaType == "text/html" {
+		name += ".html"
+	}
+	return name
+}
+
+// Where a finished download goes: an explicit output is overwritten,
+// a name picked from the response never replaces an existing file
+func finalPath(r grabRequest, dest string) string {
+	if r.Output != "" {
+		return dest
+	}
+	return uniquePath(dest)
+}
+
+// Path that doesn't exist yet: the given one or "name (n).ext"
+func uniquePath(p string) string {
+	if _, err := os.Stat(p); errors.Is(err, os.ErrNotExist) {
+		return p
+	}
+	ext := filepath.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if _, err := os.Stat(candidate); errors.Is(err, os.ErrNotExist) {
+			return candidate
+		}
+	}
+}
+
+// Reader that counts bytes into a progress bar
+type progressReader struct {
+	r   io.Reader
+	bar *progressBar
+}
+
+func (p progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.bar.add(int64(n))
+	return n, err
+}
+
+// Wrap a reader so it updates the bar (if there is one)
+func withProgress(r io.Reader, bar *progressBar) io.Reader {
+	if bar == nil {
+		return r
+	}
+	return progressReader{r: r, bar: bar}
+}
+
+// Single-line progress bar on stderr, redrawn a few times per second
+type progressBar struct {
+	name    string
+	total   int64
+	done    atomic.Int64
+	resumed int64
+	start   time.Time
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+
+	// Set for bars reported to a tracker instead of drawn
+	tracker progressTracker
+}
+
+// Start drawing a bar. total is -1 when unknown; done is what was already on disk.
+func newProgressBar(name string, total, done int64) *progressBar {
+	p := &progressBar{name: name, total: total, resumed: done, start: time.Now(), stop: make(chan struct{})}
+	p.done.Store(done)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.draw()
+			}
+		}
+	}()
+	return p
+}
+
+func (p *progressBar) add(n int64) {
+	p.done.Add(n)
+	if p.tracker != nil {
+		p.tracker.add(n)
+	}
+}
+
+// Stop redrawing and leave the final state on screen
+func (p *progressBar) finish() {
+	p.once.Do(func() {
+		if p.tracker != nil {
+			p.tracker.untrack(p)
+			return
+		}
+		close(p.stop)
+		p.wg.Wait()
+		p.draw()
+		fmt.Fprintln(os.Stderr)
+	})
+}
+
+// Redraw the bar: name, percentage, bar, size, speed and ETA
+func (p *progressBar) draw() {
+	done := p.done.Load()
+	elapsed := time.Since(p.start).Seconds()
+	speed := 0.0
+	if elapsed > 0 {
+		speed = float64(done-p.resumed) / elapsed
+	}
+
+	width, _ := terminalSize()
+	stats := fmt.Sprintf(" %s %s/s", formatBytes(done), formatBytes(int64(speed)))
+	line := "⬇️  " + p.name
+	if p.total > 0 {
+		pct := float64(done) / float64(p.total)
+		stats = fmt.Sprintf(" %3.0f%% %s / %s %s/s", pct*100, formatBytes(done), formatBytes(p.total), formatBytes(int64(speed)))
+		if speed > 0 && done < p.total {
+			eta := time.Duration(float64(p.total-done)/speed) * time.Second
+			stats += " ETA " + eta.Round(time.Second).String()
+		}
+		barWidth := min(30, width-runewidth.StringWidth(line)-runewidth.StringWidth(stats)-4)
+		if barWidth >= 10 {
+			filled := int(pct * float64(barWidth))
+			stats = " [" + strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled) + "]" + stats
+		}
+	}
+	line = runewidth.Truncate(line+stats, width-1, "…")
+	fmt.Fprint(os.Stderr, "\r"+line+strings.Repeat(" ", max(0, width-1-runewidth.StringWidth(line))))
+}
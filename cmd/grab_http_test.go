@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestGrabber() *grabber {
+	g := newGrabber()
+	g.progress = false
+	return g
+}
+
+// Server for one file that supports ranges and If-Range through http.ServeContent
+func newTestFileServer(t *testing.T, content []byte, etag string) (*httptest.Server, *atomic.Int32) {
+	var full atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") == "" {
+			full.Add(1)
+		}
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &full
+}
+
+func TestDownloadIdleTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1000")
+		w.Write([]byte("some"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	g := newTestGrabber()
+	g.idleTimeout = 100 * time.Millisecond
+	_, err := g.download(context.Background(), grabRequest{URL: srv.URL + "/stall.bin", Dir: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "no data received") {
+		t.Fatalf("expected an idle timeout, got %v", err)
+	}
+}
+
+func TestDownloadCompletePartUnchanged(t *testing.T) {
+	content := []byte("the finished file")
+	srv, full := newTestFileServer(t, content, `"v1"`)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "file.bin"+partSuffix), content, 0644)
+
+	path, err := newTestGrabber().download(context.Background(), grabRequest{URL: srv.URL + "/file.bin", Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, content) {
+		t.Errorf("got %q", got)
+	}
+	if full.Load() != 1 {
+		t.Errorf("an unchanged complete part shouldn't be downloaded again (%d full requests)", full.Load())
+	}
+}
+
+func TestDownloadCompletePartChanged(t *testing.T) {
+	content := []byte("the current file!")
+	srv, _ := newTestFileServer(t, content, `"v2"`)
+	dir := t.TempDir()
+	// Same size, but left over from an older version of the file
+	os.WriteFile(filepath.Join(dir, "file.bin"+partSuffix), []byte("an outdated file!"), 0644)
+
+	path, err := newTestGrabber().download(context.Background(), grabRequest{URL: srv.URL + "/file.bin", Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, content) {
+		t.Errorf("a stale part was kept: got %q", got)
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	for _, c := range []struct {
+		name, stored string
+		wantFull     int32
+	}{
+		{"same version", `"v1"`, 1},
+		// The ranged request is answered with the whole new file
+		{"changed on the server", `"v0"`, 1},
+		{"no stored validator", "", 1},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			srv, full := newTestFileServer(t, content, `"v1"`)
+			dir := t.TempDir()
+			part := filepath.Join(dir, "file.bin"+partSuffix)
+			// The stale case keeps different bytes to show they're thrown away
+			os.WriteFile(part, []byte("0123XXXX"), 0644)
+			if c.stored == `"v1"` {
+				os.WriteFile(part, content[:8], 0644)
+			}
+			writePartValidator(part, c.stored)
+
+			path, err := newTestGrabber().download(context.Background(), grabRequest{URL: srv.URL + "/file.bin", Dir: dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := os.ReadFile(path); !bytes.Equal(got, content) {
+				t.Errorf("got %q", got)
+			}
+			if full.Load() != c.wantFull {
+				t.Errorf("%d full requests, want %d", full.Load(), c.wantFull)
+			}
+			if fileExists(part + partValidatorSuffix) {
+				t.Error("the validator file should be removed with the partial file")
+			}
+		})
+	}
+}
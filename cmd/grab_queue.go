@@ -0,0 +1,381 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// States of a queued download
+const (
+	jobQueued   = "queued"
+	jobRunning  = "running"
+	jobPaused   = "paused"
+	jobDone     = "done"
+	jobFailed   = "failed"
+	jobCanceled = "canceled"
+)
+
+// A download in the persistent queue
+type queueJob struct {
+	ID          int       `json:"id"`
+	URL         string    `json:"url"`
+	Dir         string    `json:"dir"`
+	Output      string    `json:"output,omitempty"`
+	Connections int       `json:"connections,omitempty"`
+	State       string    `json:"state"`
+	Path        string    `json:"path,omitempty"` // destination, once known
+	Size        int64     `json:"size,omitempty"`
+	Done        int64     `json:"done,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Added       time.Time `json:"added"`
+	Finished    time.Time `json:"finished,omitzero"`
+}
+
+// The download queue as stored on disk
+type grabQueue struct {
+	NextID int         `json:"next_id"`
+	Jobs   []*queueJob `json:"jobs"`
+}
+
+// A command sent to the daemon (or applied to the queue file when none is running)
+type queueRequest struct {
+	Op          string `json:"op"` // add, status, pause, resume, cancel
+	ID          int    `json:"id,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Dir         string `json:"dir,omitempty"`
+	Output      string `json:"output,omitempty"`
+	Connections int    `json:"connections,omitempty"`
+}
+
+// The answer to a request
+type queueResponse struct {
+	Error string      `json:"error,omitempty"`
+	Jobs  []*queueJob `json:"jobs,omitempty"`
+}
+
+// Queue file and daemon socket, both in the data directory
+func grabQueuePath() string {
+	return filepath.Join(brightsideDataDir(), "grab_queue.json")
+}
+
+func grabSocketPath() string {
+	return filepath.Join(brightsideDataDir(), "grab.sock")
+}
+
+func loadGrabQueue() (*grabQueue, error) {
+	q := &grabQueue{NextID: 1}
+	if err := readJSONFile(grabQueuePath(), q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *grabQueue) save() error {
+	return writeJSONFile(grabQueuePath(), q)
+}
+
+func (q *grabQueue) job(id int) *queueJob {
+	for _, job := range q.Jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// Apply a request to the queue. Only the state changes here; the daemon
+// starts and stops the actual downloads to match.
+func (q *grabQueue) handle(req queueRequest) queueResponse {
+	if req.Op == "add" {
+		if isVideoPlatform(req.URL) {
+			return queueResponse{Error: errVideoQueue.Error()}
+		}
+		job := &queueJob{
+			ID:          q.NextID,
+			URL:         req.URL,
+			Dir:         req.Dir,
+			Output:      req.Output,
+			Connections: req.Connections,
+			State:       jobQueued,
+			Added:       time.Now(),
+		}
+		q.NextID++
+		q.Jobs = append(q.Jobs, job)
+		return queueResponse{Jobs: []*queueJob{job}}
+	}
+	if req.Op == "status" && req.ID == 0 {
+		return queueResponse{Jobs: q.Jobs}
+	}
+
+	job := q.job(req.ID)
+	if job == nil {
+		return queueResponse{Error: fmt.Sprintf("no download #%d", req.ID)}
+	}
+	switch req.Op {
+	case "status":
+	case "pause":
+		if job.State != jobQueued && job.State != jobRunning {
+			return queueResponse{Error: fmt.Sprintf("download #%d is %s", job.ID, job.State)}
+		}
+		job.State = jobPaused
+	case "resume":
+		if job.State != jobPaused && job.State != jobFailed {
+			return queueResponse{Error: fmt.Sprintf("download #%d is %s", job.ID, job.State)}
+		}
+		job.State, job.Error = jobQueued, ""
+	case "cancel":
+		if job.State == jobDone || job.State == jobCanceled {
+			return queueResponse{Error: fmt.Sprintf("download #%d is already %s", job.ID, job.State)}
+		}
+		job.State = jobCanceled
+		job.Finished = time.Now()
+	default:
+		return queueResponse{Error: fmt.Sprintf("unknown request %q", req.Op)}
+	}
+	return queueResponse{Jobs: []*queueJob{job}}
+}
+
+// Returned for video URLs, which the daemon can't download
+var errVideoQueue = errors.New("videos can't be queued; download them with `grab <url>`")
+
+// Send a request to the daemon. Without a daemon the queue file is updated
+// directly, and the daemon picks the change up when it next starts.
+func sendQueueRequest(req queueRequest) (queueResponse, bool, error) {
+	conn, err := net.DialTimeout("unix", grabSocketPath(), time.Second)
+	if err != nil {
+		// A daemon that is too busy to answer must not have its queue edited
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return queueResponse{}, true, fmt.Errorf("the daemon isn't answering: %w", err)
+		}
+		return editGrabQueue(req)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return queueResponse{}, true, err
+	}
+	var resp queueResponse
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		return queueResponse{}, true, fmt.Errorf("no answer from the daemon: %w", err)
+	}
+	return resp, true, nil
+}
+
+// Apply a request to the queue file. The daemon holds the queue's lock for as
+// long as it runs, so a lock that stays taken means a daemon the socket
+// couldn't reach.
+func editGrabQueue(req queueRequest) (queueResponse, bool, error) {
+	lock, err := tryLockFile(grabQueuePath(), 2*time.Second)
+	if errors.Is(err, errLocked) {
+		return queueResponse{}, true, errors.New("the daemon is running but isn't answering")
+	}
+	if err != nil {
+		return queueResponse{}, false, err
+	}
+	defer lock.unlock()
+
+	q, err := loadGrabQueue()
+	if err != nil {
+		return queueResponse{}, false, err
+	}
+	resp := q.handle(req)
+	if req.Op != "status" && resp.Error == "" {
+		err = q.save()
+	}
+	if req.Op == "cancel" && resp.Error == "" {
+		removePartial(resp.Jobs[0])
+	}
+	return resp, false, err
+}
+
+// Send a request and print any error. Returns the jobs it concerned,
+// whether the daemon answered, and whether it succeeded.
+func queueCommand(req queueRequest) ([]*queueJob, bool, bool) {
+	resp, daemon, err := sendQueueRequest(req)
+	if err == nil && resp.Error != "" {
+		err = errors.New(resp.Error)
+	}
+	if err != nil {
+		fmt.Println("❌", err)
+		return nil, daemon, false
+	}
+	return resp.Jobs, daemon, true
+}
+
+// Icon for a job state
+func jobIcon(state string) string {
+	switch state {
+	case jobRunning:
+		return "⬇️"
+	case jobPaused:
+		return "⏸️"
+	case jobDone:
+		return "✅"
+	case jobFailed:
+		return "❌"
+	case jobCanceled:
+		return "🚫"
+	}
+	return "🕒"
+}
+
+// Print one job: state, progress and where it goes
+func printQueueJob(job *queueJob) {
+	name := job.URL
+	if job.Path != "" {
+		name = filepath.Base(job.Path)
+	}
+	progress := ""
+	switch {
+	case job.State == jobCanceled || job.State == jobFailed:
+	case job.State == jobDone && job.Size > 0:
+		progress = formatBytes(job.Size)
+	case job.Size > 0:
+		progress = fmt.Sprintf("%.0f%% of %s", float64(job.Done)/float64(job.Size)*100, formatBytes(job.Size))
+	case job.Done > 0:
+		progress = formatBytes(job.Done)
+	}
+	fmt.Printf("%s %s#%d%s %-8s %s", jobIcon(job.State), Cyan, job.ID, Reset, job.State, name)
+	if progress != "" {
+		fmt.Printf(Gray+" (%s)"+Reset, progress)
+	}
+	fmt.Println()
+	if job.Path != "" && job.State != jobDone {
+		fmt.Printf(Gray+"     %s\n"+Reset, job.URL)
+	}
+	if job.Error != "" {
+		fmt.Printf("     ⚠️ %s\n", job.Error)
+	}
+}
+
+// Parse the job ID argument of status/pause/resume/cancel
+func parseJobID(arg string) (int, bool) {
+	id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil || id < 1 {
+		fmt.Printf("❌ Invalid download ID %q\n", arg)
+		return 0, false
+	}
+	return id, true
+}
+
+// grabAddCmd queues a download for the daemon
+var grabAddCmd = &cobra.Command{
+	Use:   "add [URL]...",
+	Short: "Add downloads to the queue processed by `grab daemon`",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := filepath.Abs(grabDir)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		output := grabOutput
+		if output != "" {
+			if len(args) > 1 {
+				fmt.Println("❌ --output only works with a single URL.")
+				return
+			}
+			if output, err = filepath.Abs(output); err != nil {
+				fmt.Println("❌", err)
+				return
+			}
+		}
+
+		// Refuse videos before queueing any of the URLs
+		for _, u := range args {
+			if isVideoPlatform(u) {
+				fmt.Printf("❌ %s is a video, which the daemon can't download. Download it with: %s grab %s\n", u, rootCmd.Name(), u)
+				return
+			}
+		}
+
+		daemon := false
+		for _, u := range args {
+			jobs, running, ok := queueCommand(queueRequest{Op: "add", URL: u, Dir: dir, Output: output, Connections: grabConnections})
+			if !ok {
+				return
+			}
+			daemon = running
+			fmt.Printf(Green+"✅ Queued #%d: %s\n"+Reset, jobs[0].ID, u)
+		}
+		if !daemon {
+			fmt.Println("💡 The daemon isn't running. Start it with: " + rootCmd.Name() + " grab daemon")
+		}
+	},
+}
+
+// grabStatusCmd shows the queue, or one download
+var grabStatusCmd = &cobra.Command{
+	Use:   "status [id]",
+	Short: "Show queued downloads",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := queueRequest{Op: "status"}
+		if len(args) == 1 {
+			id, ok := parseJobID(args[0])
+			if !ok {
+				return
+			}
+			req.ID = id
+		}
+		jobs, daemon, ok := queueCommand(req)
+		if !ok {
+			return
+		}
+		if daemon {
+			fmt.Println(Blue + "📥 Download queue (daemon running)" + Reset)
+		} else {
+			fmt.Println(Blue + "📥 Download queue (daemon stopped)" + Reset)
+		}
+		if len(jobs) == 0 {
+			fmt.Println("  Nothing queued. Add downloads with: " + rootCmd.Name() + " grab add <url>")
+			return
+		}
+		for _, job := range jobs {
+			// Left running by a daemon that didn't stop cleanly; it restarts them
+			if !daemon && job.State == jobRunning {
+				job.State = jobQueued
+			}
+			printQueueJob(job)
+		}
+	},
+}
+
+// Build pause/resume/cancel, which only differ in the request they send
+func newQueueControlCmd(op, short, done string) *cobra.Command {
+	return &cobra.Command{
+		Use:   op + " <id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			id, ok := parseJobID(args[0])
+			if !ok {
+				return
+			}
+			if _, _, ok := queueCommand(queueRequest{Op: op, ID: id}); ok {
+				fmt.Printf(Green+"✅ %s #%d\n"+Reset, done, id)
+			}
+		},
+	}
+}
+
+func init() {
+	grabCmd.AddCommand(grabAddCmd)
+	grabAddCmd.Flags().StringVarP(&grabOutput, "output", "o", "", "Save to this file instead of the server's file name")
+	grabAddCmd.Flags().StringVarP(&grabDir, "dir", "d", ".", "Directory to save files in")
+	grabAddCmd.Flags().IntVarP(&grabConnections, "connections", "c", 1, "Download large files over this many connections at once")
+	grabCmd.AddCommand(grabStatusCmd)
+	grabCmd.AddCommand(newQueueControlCmd("pause", "Pause a queued download, keeping what was downloaded", "Paused"))
+	grabCmd.AddCommand(newQueueControlCmd("resume", "Resume a paused or failed download", "Resumed"))
+	grabCmd.AddCommand(newQueueControlCmd("cancel", "Cancel a download and delete its partial file", "Canceled"))
+}
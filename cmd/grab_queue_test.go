@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestGrabQueueHandle(t *testing.T) {
+	q := &grabQueue{NextID: 1}
+	steps := []struct {
+		req       queueRequest
+		wantState string
+		wantErr   bool
+	}{
+		{queueRequest{Op: "add", URL: "https://a.example/1"}, jobQueued, false},
+		{queueRequest{Op: "add", URL: "https://a.example/2"}, jobQueued, false},
+		{queueRequest{Op: "add", URL: "https://youtu.be/x"}, "", true},
+		{queueRequest{Op: "resume", ID: 1}, "", true},
+		{queueRequest{Op: "pause", ID: 1}, jobPaused, false},
+		{queueRequest{Op: "pause", ID: 1}, "", true},
+		{queueRequest{Op: "status", ID: 1}, jobPaused, false},
+		{queueRequest{Op: "resume", ID: 1}, jobQueued, false},
+		{queueRequest{Op: "cancel", ID: 2}, jobCanceled, false},
+		{queueRequest{Op: "cancel", ID: 2}, "", true},
+		{queueRequest{Op: "pause", ID: 2}, "", true},
+		{queueRequest{Op: "resume", ID: 2}, "", true},
+		{queueRequest{Op: "pause", ID: 3}, "", true},
+		{queueRequest{Op: "restart", ID: 1}, "", true},
+	}
+	for _, step := range steps {
+		resp := q.handle(step.req)
+		if (resp.Error != "") != step.wantErr {
+			t.Fatalf("%+v: error %q, want error: %v", step.req, resp.Error, step.wantErr)
+		}
+		if !step.wantErr && resp.Jobs[0].State != step.wantState {
+			t.Fatalf("%+v: state %s, want %s", step.req, resp.Jobs[0].State, step.wantState)
+		}
+	}
+
+	if len(q.Jobs) != 2 || q.Jobs[0].ID != 1 || q.Jobs[1].ID != 2 || q.NextID != 3 {
+		t.Errorf("unexpected jobs %+v, next ID %d", q.Jobs, q.NextID)
+	}
+	if resp := q.handle(queueRequest{Op: "status"}); len(resp.Jobs) != 2 {
+		t.Errorf("status listed %d jobs", len(resp.Jobs))
+	}
+
+	// A failed download can be retried
+	q.Jobs[1].State, q.Jobs[1].Error = jobFailed, "boom"
+	if resp := q.handle(queueRequest{Op: "resume", ID: 2}); resp.Error != "" || resp.Jobs[0].State != jobQueued || resp.Jobs[0].Error != "" {
+		t.Errorf("resume of a failed download: %+v", resp)
+	}
+}
+
+func TestEditGrabQueueConcurrentAdds(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, daemon, err := sendQueueRequest(queueRequest{Op: "add", URL: "https://a.example/file"}); err != nil || daemon {
+				t.Errorf("add without a daemon: daemon=%v err=%v", daemon, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	q, err := loadGrabQueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int]bool{}
+	for _, job := range q.Jobs {
+		seen[job.ID] = true
+	}
+	if len(q.Jobs) != 8 || len(seen) != 8 || q.NextID != 9 {
+		t.Errorf("got %d jobs with %d distinct IDs, next ID %d", len(q.Jobs), len(seen), q.NextID)
+	}
+}
+
+func TestEditGrabQueueWhileLocked(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	// What a daemon holds while it runs
+	lock, err := lockFile(grabQueuePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.unlock()
+
+	if _, _, err := sendQueueRequest(queueRequest{Op: "add", URL: "https://a.example/file"}); err == nil {
+		t.Error("the queue file was edited while locked")
+	}
+	if fileExists(grabQueuePath()) {
+		t.Error("the queue file was written")
+	}
+}
@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Suffix of the state file kept next to a segmented partial download
+const segmentStateSuffix = ".json"
+
+// Files smaller than this are always fetched in one stream
+const minSegmentedSize = 1 << 20
+
+// Attempts per segment before the download gives up
+const segmentRetries = 5
+
+// A byte range of the file and how much of it is on disk
+type segment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"` // inclusive
+	Done  int64 `json:"done"`
+}
+
+func (s *segment) size() int64 {
+	return s.End - s.Start + 1
+}
+
+// Progress of a segmented download, saved next to the partial file
+type segmentState struct {
+	URL       string     `json:"url"`
+	Size      int64      `json:"size"`
+	Validator string     `json:"validator,omitempty"`
+	Segments  []*segment `json:"segments"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// Split a file into n ranges of (nearly) equal size
+func splitSegments(size int64, n int) []*segment {
+	n = int(min(int64(n), size))
+	chunk := size / int64(n)
+	segments := make([]*segment, n)
+	for i := range segments {
+		start := int64(i) * chunk
+		end := start + chunk - 1
+		if i == n-1 {
+			end = size - 1
+		}
+		segments[i] = &segment{Start: start, End: end}
+	}
+	return segments
+}
+
+// Load the state of an earlier attempt if it is for the same file, otherwise start fresh
+func loadSegmentState(path, url string, size int64, validator string, connections int) *segmentState {
+	var s segmentState
+	if err := readJSONFile(path, &s); err == nil && s.Size == size && s.Validator == validator && len(s.Segments) > 0 {
+		s.path = path
+		return &s
+	}
+	return &segmentState{URL: url, Size: size, Validator: validator, Segments: splitSegments(size, connections), path: path}
+}
+
+// Save the state. The progress is recorded before the partial file is
+// synced, so the state never claims bytes that aren't on disk yet.
+func (s *segmentState) save(f *os.File) error {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data, 0644)
+}
+
+// Bytes already on disk
+func (s *segmentState) done() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, seg := range s.Segments {
+		n += seg.Done
+	}
+	return n
+}
+
+// Check that every range was written completely
+func (s *segmentState) verify() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var covered int64
+	for i, seg := range s.Segments {
+		if seg.Done != seg.size() {
+			return fmt.Errorf("segment %d incomplete: %s of %s", i+1, formatBytes(seg.Done), formatBytes(seg.size()))
+		}
+		if i > 0 && seg.Start != s.Segments[i-1].End+1 {
+			return fmt.Errorf("segment %d does not follow segment %d", i+1, i)
+		}
+		covered += seg.size()
+	}
+	if covered != s.Size {
+		return fmt.Errorf("segments cover %s of %s", formatBytes(covered), formatBytes(s.Size))
+	}
+	return nil
+}
+
+// Whether a file's partial download was started in segments
+func hasSegmentState(part string) bool {
+	return fileExists(part + segmentStateSuffix)
+}
+
+// Download a file over several connections at once. Each segment is written
+// in place into the partial file and retried on its own; the state file lets
+// an interrupted download continue where it stopped.
+func (g *grabber) downloadSegments(ctx context.Context, url, part string, size int64, validator, dest string) error {
+	state := loadSegmentState(part+segmentStateSuffix, url, size, validator, g.connections)
+
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+
+	bar := g.startProgress(dest, size, state.done())
+	if bar != nil {
+		defer bar.finish()
+	}
+
+	// Save the state regularly so a crash loses little work
+	stopSaving := make(chan struct{})
+	var saver sync.WaitGroup
+	saver.Add(1)
+	go func() {
+		defer saver.Done()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopSaving:
+				return
+			case <-ticker.C:
+				state.save(f)
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, len(state.Segments))
+	var wg sync.WaitGroup
+	for _, seg := range state.Segments {
+		if seg.Done == seg.size() {
+			continue
+		}
+		wg.Add(1)
+		go func(seg *segment) {
+			defer wg.Done()
+			if err := g.fetchSegment(ctx, url, validator, f, seg, state, bar); err != nil {
+				errs <- err
+				cancel()
+			}
+		}(seg)
+	}
+	wg.Wait()
+	close(stopSaving)
+	saver.Wait()
+	close(errs)
+
+	if err := state.save(f); err != nil {
+		return err
+	}
+	if err := <-errs; err != nil {
+		return err
+	}
+	if err := state.verify(); err != nil {
+		return err
+	}
+	return os.Remove(state.path)
+}
+
+// Fetch the rest of one segment, retrying with backoff
+func (g *grabber) fetchSegment(ctx context.Context, url, validator string, f *os.File, seg *segment, state *segmentState, bar *progressBar) error {
+	var err error
+	for attempt := 0; attempt < segmentRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(1<<(attempt-1)) * time.Second):
+			}
+		}
+		if err = g.fetchRange(ctx, url, validator, f, seg, state, bar); err == nil || ctx.Err() != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("bytes %d-%d: %w", seg.Start, seg.End, err)
+}
+
+// One attempt at a segment: request the missing range and write it in place
+func (g *grabber) fetchRange(ctx context.Context, url, validator string, f *os.File, seg *segment, state *segmentState, bar *progressBar) error {
+	state.mu.Lock()
+	from := seg.Start + seg.Done
+	state.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", grabUserAgent)
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", from, seg.End))
+	if validator != "" {
+		req.Header.Set("If-Range", validator)
+	}
+	if err := g.hosts.acquire(ctx, req.URL.Host); err != nil {
+		return err
+	}
+	defer g.hosts.release(req.URL.Host)
+	resp, err := g.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return &httpStatusError{URL: url, Status: resp.Status + " (expected 206 Partial Content)"}
+	}
+	if want := fmt.Sprintf("bytes %d-%d/", from, seg.End); !strings.HasPrefix(resp.Header.Get("Content-Range"), want) {
+		return fmt.Errorf("server sent range %q, wanted %q", resp.Header.Get("Content-Range"), want+"*")
+	}
+
+	buf := make([]byte, 64*1024)
+	offset := from
+	for offset <= seg.End {
+		n, rerr := resp.Body.Read(buf)
+		if n > 0 {
+			n = int(min(int64(n), seg.End-offset+1))
+			if _, err := f.WriteAt(buf[:n], offset); err != nil {
+				return err
+			}
+			offset += int64(n)
+			state.mu.Lock()
+			seg.Done = offset - seg.Start
+			state.mu.Unlock()
+			if bar != nil {
+				bar.add(int64(n))
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+	if offset <= seg.End {
+		return errors.New("connection closed early")
+	}
+	return nil
+}
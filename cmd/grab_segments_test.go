@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSplitSegments(t *testing.T) {
+	cases := []struct {
+		size int64
+		n    int
+		want [][2]int64
+	}{
+		{10, 1, [][2]int64{{0, 9}}},
+		{10, 3, [][2]int64{{0, 2}, {3, 5}, {6, 9}}},
+		{4, 4, [][2]int64{{0, 0}, {1, 1}, {2, 2}, {3, 3}}},
+		{2, 8, [][2]int64{{0, 0}, {1, 1}}},
+	}
+	for _, c := range cases {
+		segments := splitSegments(c.size, c.n)
+		if len(segments) != len(c.want) {
+			t.Errorf("splitSegments(%d, %d): %d segments, want %d", c.size, c.n, len(segments), len(c.want))
+			continue
+		}
+		for i, seg := range segments {
+			if seg.Start != c.want[i][0] || seg.End != c.want[i][1] || seg.Done != 0 {
+				t.Errorf("splitSegments(%d, %d)[%d] = %+v, want %v", c.size, c.n, i, *seg, c.want[i])
+			}
+		}
+	}
+}
+
+func TestDownloadSegmentsResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), minSegmentedSize/16*2)
+	var mu sync.Mutex
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "big.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	// An earlier run finished the first of four segments and half of the second
+	dir := t.TempDir()
+	part := filepath.Join(dir, "big.bin"+partSuffix)
+	size := int64(len(content))
+	segments := splitSegments(size, 4)
+	segments[0].Done = segments[0].size()
+	segments[1].Done = segments[1].size() / 2
+	data := make([]byte, size)
+	copy(data, content[:segments[1].Start+segments[1].Done])
+	os.WriteFile(part, data, 0644)
+	state := segmentState{URL: srv.URL + "/big.bin", Size: size, Validator: `"v1"`, Segments: segments}
+	if err := writeJSONFile(part+segmentStateSuffix, &state); err != nil {
+		t.Fatal(err)
+	}
+
+	g := newTestGrabber()
+	g.connections = 4
+	path, err := g.download(context.Background(), grabRequest{URL: srv.URL + "/big.bin", Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, content) {
+		t.Error("the resumed file doesn't match the original")
+	}
+	if fileExists(part + segmentStateSuffix) {
+		t.Error("the state file should be removed once the download is complete")
+	}
+
+	// Only the missing ranges were requested after the first GET
+	for _, r := range ranges[1:] {
+		if r == "" || strings.HasPrefix(r, "bytes=0-") {
+			t.Errorf("requested %q, which was already on disk", r)
+		}
+	}
+	want := fmt.Sprintf("bytes=%d-%d", segments[1].Start+segments[1].Done, segments[1].End)
+	if !slices.Contains(ranges, want) {
+		t.Errorf("the second segment should continue with %q, got %q", want, ranges)
+	}
+	if len(ranges) != 4 {
+		t.Errorf("expected the first GET and 3 ranged requests, got %q", ranges)
+	}
+}
@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Suffix of downloads kept after failing verification
+const quarantineSuffix = ".quarantine"
+
+// Largest file checked against a legacy (not prehashed) minisign signature,
+// which signs the whole file and so needs it in memory
+const maxLegacySignedSize = 128 << 20
+
+// Checksum algorithms grab can verify
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// What a download is checked against before it's moved into place
+type grabVerify struct {
+	Sums       map[string]string // expected hex digest by algorithm
+	SumsURL    string            // SHA256SUMS-style file that lists the download
+	Signature  string            // minisign signature file or URL
+	PublicKey  string            // minisign public key, or a file holding it
+	Quarantine bool              // keep a file that fails as <name>.quarantine instead of deleting it
+
+	// Filled in by a successful check
+	Passed         []string
+	TrustedComment string
+}
+
+// Download that didn't match its checksum or signature
+type verifyError struct {
+	What string
+	Err  error
+}
+
+func (e *verifyError) Error() string {
+	return fmt.Sprintf("%s verification failed: %v", e.What, e.Err)
+}
+
+// Check the expected digests given on the command line
+func (v *grabVerify) validate() error {
+	for algo, sum := range v.Sums {
+		b, err := hex.DecodeString(sum)
+		if err != nil || len(b) != checksumAlgorithms[algo]().Size() {
+			return fmt.Errorf("invalid %s checksum %q", algo, sum)
+		}
+	}
+	if v.Signature != "" && v.PublicKey == "" {
+		return errors.New("a signature needs a public key (--minisign-key)")
+	}
+	return nil
+}
+
+// Hashes a download as it's written and checks it at the end
+type verifier struct {
+	spec   *grabVerify
+	want   map[string][]byte
+	hashes map[string]hash.Hash
+	sig    *minisignSignature
+	key    *minisignKey
+	hashed int64 // bytes of the file fed to the hashes so far
+}
+
+// Get everything needed to check a download before it starts, so a missing
+// checksum or signature doesn't waste the transfer. fileURL is the URL as
+// given, before redirects, which usually lead to signed URLs that don't have
+// a signature next to them. Returns nil if there is nothing to check.
+func (v *grabVerify) prepare(ctx context.Context, g *grabber, fileURL, dest string) (*verifier, error) {
+	if v == nil {
+		return nil, nil
+	}
+	c := &verifier{spec: v, want: map[string][]byte{}, hashes: map[string]hash.Hash{}}
+	for algo, sum := range v.Sums {
+		c.want[algo], _ = hex.DecodeString(sum)
+	}
+
+	if v.SumsURL != "" {
+		body, err := g.fetchSmall(ctx, v.SumsURL)
+		if err != nil {
+			return nil, fmt.Errorf("checksum file: %w", err)
+		}
+		algo, sum, err := findChecksum(body, checksumNames(fileURL, dest))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.SumsURL, err)
+		}
+		if want, ok := c.want[algo]; ok && !bytes.Equal(want, sum) {
+			return nil, fmt.Errorf("%s checksum from %s doesn't match the one given", algo, v.SumsURL)
+		}
+		c.want[algo] = sum
+	}
+	for algo := range c.want {
+		c.hashes[algo] = checksumAlgorithms[algo]()
+	}
+
+	if v.PublicKey != "" {
+		key, err := loadMinisignKey(v.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		sigRef := v.Signature
+		if sigRef == "" {
+			sigRef = fileURL + ".minisig"
+		}
+		var data []byte
+		if fileExists(sigRef) {
+			data, err = os.ReadFile(sigRef)
+		} else {
+			data, err = g.fetchSmall(ctx, sigRef)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("signature: %w", err)
+		}
+		sig, err := parseMinisignSignature(data)
+		if err != nil {
+			return nil, fmt.Errorf("signature %s: %w", sigRef, err)
+		}
+		if sig.KeyID != key.ID {
+			return nil, fmt.Errorf("signature %s was made with key %X, not %X", sigRef, sig.KeyID, key.ID)
+		}
+		c.key, c.sig = key, sig
+		if sig.Prehashed {
+			c.hashes["blake2b"], _ = blake2b.New512(nil)
+		}
+	}
+	return c, nil
+}
+
+// Feed downloaded bytes to every hash
+func (c *verifier) Write(p []byte) (int, error) {
+	for _, h := range c.hashes {
+		h.Write(p)
+	}
+	c.hashed += int64(len(p))
+	return len(p), nil
+}
+
+// Tee a download into the hashes (if anything is checked)
+func (c *verifier) writer(w io.Writer) io.Writer {
+	if c == nil {
+		return w
+	}
+	return io.MultiWriter(w, c)
+}
+
+// Hash the part of a file that didn't stream through the verifier, such as
+// the start of a resumed download or a file fetched in segments
+func (c *verifier) catchUp(path string) error {
+	if c == nil || len(c.hashes) == 0 {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(c.hashed, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(c, f)
+	return err
+}
+
+// Compare the digests and check the signature of a complete file
+func (c *verifier) check(path string) error {
+	if c == nil {
+		return nil
+	}
+	if err := c.catchUp(path); err != nil {
+		return err
+	}
+	for _, algo := range slices.Sorted(maps.Keys(c.want)) {
+		if got := c.hashes[algo].Sum(nil); !bytes.Equal(got, c.want[algo]) {
+			return &verifyError{What: algo, Err: fmt.Errorf("expected %x, got %x", c.want[algo], got)}
+		}
+		c.spec.Passed = append(c.spec.Passed, algo)
+	}
+	if c.sig == nil {
+		return nil
+	}
+
+	var message []byte
+	if c.sig.Prehashed {
+		message = c.hashes["blake2b"].Sum(nil)
+	} else {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxLegacySignedSize {
+			return &verifyError{What: "signature", Err: fmt.Errorf("legacy signatures are only checked for files up to %s; sign large files with minisign -H", formatBytes(maxLegacySignedSize))}
+		}
+		if message, err = os.ReadFile(path); err != nil {
+			return err
+		}
+	}
+	if !ed25519.Verify(c.key.Key, message, c.sig.Signature) {
+		return &verifyError{What: "signature", Err: errors.New("the file doesn't match the signature")}
+	}
+	if !ed25519.Verify(c.key.Key, append(slices.Clone(c.sig.Signature), c.sig.TrustedComment...), c.sig.GlobalSignature) {
+		return &verifyError{What: "signature", Err: errors.New("the trusted comment was tampered with")}
+	}
+	c.spec.Passed = append(c.spec.Passed, "minisign signature")
+	c.spec.TrustedComment = c.sig.TrustedComment
+	return nil
+}
+
+// Deal with a file that failed verification: delete it, or move it aside
+// where it won't be mistaken for the real thing
+func (c *verifier) reject(part, dest string) (string, error) {
+	if !c.spec.Quarantine {
+		return "", os.Remove(part)
+	}
+	quarantined := uniquePath(dest + quarantineSuffix)
+	return quarantined, os.Rename(part, quarantined)
+}
+
+// Names a download may be listed under in a checksum file
+func checksumNames(fileURL, dest string) []string {
+	names := []string{filepath.Base(dest)}
+	if u, err := url.Parse(fileURL); err == nil {
+		if p, err := url.PathUnescape(path.Base(u.Path)); err == nil && p != names[0] {
+			names = append(names, p)
+		}
+	}
+	return names
+}
+
+// Find a file's entry in a checksum list. Both the coreutils format
+// ("<hex>  name", "<hex> *name") and the BSD one ("SHA256 (name) = <hex>")
+// are understood; the algorithm follows from the digest length.
+func findChecksum(list []byte, names []string) (string, []byte, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var sum, name string
+		if open := strings.Index(line, " ("); open > 0 && strings.Contains(line, ") = ") {
+			rest := line[open+2:]
+			name, sum, _ = strings.Cut(rest, ") = ")
+		} else {
+			fields := strings.SplitN(line, " ", 2)
+			if len(fields) != 2 {
+				continue
+			}
+			sum = fields[0]
+			name = strings.TrimPrefix(strings.TrimLeft(fields[1], " "), "*")
+		}
+		name = strings.TrimPrefix(name, "./")
+		if !slices.Contains(names, name) && !slices.Contains(names, path.Base(name)) {
+			continue
+		}
+		digest, err := hex.DecodeString(strings.TrimSpace(sum))
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid checksum for %s", name)
+		}
+		for algo, newHash := range checksumAlgorithms {
+			if newHash().Size() == len(digest) {
+				return algo, digest, nil
+			}
+		}
+		return "", nil, fmt.Errorf("unknown checksum type for %s", name)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+	return "", nil, fmt.Errorf("no checksum listed for %s", names[0])
+}
+
+// minisign public key: algorithm, key ID and the ed25519 key
+type minisignKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// Detached minisign signature
+type minisignSignature struct {
+	Prehashed       bool // signs the BLAKE2b-512 hash of the file instead of the file
+	KeyID           [8]byte
+	Signature       []byte
+	TrustedComment  string
+	GlobalSignature []byte
+}
+
+// Read a public key given directly (the base64 line of a .pub file) or as a file
+func loadMinisignKey(ref string) (*minisignKey, error) {
+	text := ref
+	if fileExists(ref) {
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(line)
+		if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+			return nil, errors.New("invalid minisign public key")
+		}
+		key := &minisignKey{Key: ed25519.PublicKey(raw[10:])}
+		copy(key.ID[:], raw[2:10])
+		return key, nil
+	}
+	return nil, errors.New("empty minisign public key")
+}
+
+// Parse a .minisig file: untrusted comment, signature, trusted comment, global signature
+func parseMinisignSignature(data []byte) (*minisignSignature, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "untrusted comment:") {
+		return nil, errors.New("not a minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return nil, errors.New("invalid signature line")
+	}
+	sig := &minisignSignature{Signature: raw[10:]}
+	copy(sig.KeyID[:], raw[2:10])
+	switch string(raw[:2]) {
+	case "Ed":
+	case "ED":
+		sig.Prehashed = true
+	default:
+		return nil, fmt.Errorf("unsupported signature algorithm %q", raw[:2])
+	}
+
+	comment, ok := strings.CutPrefix(lines[2], "trusted comment: ")
+	if !ok {
+		return nil, errors.New("missing trusted comment")
+	}
+	sig.TrustedComment = comment
+	sig.GlobalSignature, err = base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(sig.GlobalSignature) != ed25519.SignatureSize {
+		return nil, errors.New("invalid global signature")
+	}
+	return sig, nil
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFindChecksum(t *testing.T) {
+	const sha256Hex = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	const md5Hex = "098f6bcd4621d373cade4e832627b4f6"
+	list := strings.Join([]string{
+		"# release checksums",
+		sha256Hex + "  app-linux.tar.gz",
+		sha256Hex + " *app-windows.zip",
+		sha256Hex + "  ./dist/app-darwin.tar.gz",
+		"SHA256 (app.deb) = " + sha256Hex,
+		"MD5 (app.rpm) = " + md5Hex,
+		md5Hex + "  app.apk",
+	}, "\n")
+
+	cases := []struct {
+		name, algo string
+	}{
+		{"app-linux.tar.gz", "sha256"},
+		{"app-windows.zip", "sha256"},
+		{"app-darwin.tar.gz", "sha256"},
+		{"app.deb", "sha256"},
+		{"app.rpm", "md5"},
+		{"app.apk", "md5"},
+	}
+	for _, c := range cases {
+		algo, sum, err := findChecksum([]byte(list), []string{c.name})
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if algo != c.algo || len(sum) == 0 {
+			t.Errorf("%s: got %s %x, want %s", c.name, algo, sum, c.algo)
+		}
+	}
+
+	if _, _, err := findChecksum([]byte(list), []string{"missing.tar.gz"}); err == nil {
+		t.Error("a file missing from the list should be an error")
+	}
+	if _, _, err := findChecksum([]byte("zz  bad.bin\n"), []string{"bad.bin"}); err == nil {
+		t.Error("a digest that isn't hex should be an error")
+	}
+}
+
+// Test vectors of aead.dev/minisign, made with its test key: "Hello World!\n"
+// signed in the legacy format and prehashed with BLAKE2b-512
+const (
+	minisignTestKey     = "RWRQhGcHOBlzw4CoKyugkk4ioDfoxlXxC9LBx+VNhJ3w9w+cAxgvPsuo"
+	minisignTestMessage = "Hello World!\n"
+	minisignTestLegacy  = `untrusted comment: signature from minisign secret key
+RWRQhGcHOBlzwxrJCyuC+rJfHSfyRKRxkuwa3JJ0bWEs7RHjL1OUmqnTr+V1B9JzFuJIH/ybR2Eus9oEZKt9RbitpF/L4D3+5wg=
+trusted comment: timestamp:1614549543	file:message.txt
+P/722+ynQ+tIy0qadFHwLx5MsyNz/jDKJkDWQj4dDD2OKnVte8m/M14mwPE/1NMwzShPMSBhMXqZGdbe+UZjDg==
+`
+	minisignTestPrehashed = `untrusted comment: signature from minisign secret key
+RURQhGcHOBlzw9A0iIG1NInPgFSlBIK7WVg2vTLPEV9OUzL58hoow17iZnhg8AnK6H2vApDONudOfNpP3PYHccIByxPz/vo/QQU=
+trusted comment: timestamp:1614549543	file:message.txt	hashed
+51YVmiE4S3JKXMTHmeO4wrx7KNrCjPjVgehI42q+01dlKbkvcvbktJO5MPyyRS9fAMtxrafHvvaB91EsuQ+7CA==
+`
+)
+
+func TestParseMinisignSignature(t *testing.T) {
+	key, err := loadMinisignKey(minisignTestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		sig       string
+		prehashed bool
+	}{{minisignTestLegacy, false}, {minisignTestPrehashed, true}} {
+		sig, err := parseMinisignSignature([]byte(c.sig))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sig.Prehashed != c.prehashed || sig.KeyID != key.ID || !strings.HasPrefix(sig.TrustedComment, "timestamp:1614549543") {
+			t.Errorf("unexpected signature %+v", sig)
+		}
+	}
+	if _, err := parseMinisignSignature([]byte("untrusted comment: x\nnot base64\n")); err == nil {
+		t.Error("a malformed signature should be an error")
+	}
+}
+
+// Check a file against a signature the way a download is checked
+func checkMinisign(t *testing.T, message, signature string) error {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "message.txt")
+	sigFile := file + ".minisig"
+	os.WriteFile(file, []byte(message), 0644)
+	os.WriteFile(sigFile, []byte(signature), 0644)
+
+	spec := &grabVerify{PublicKey: minisignTestKey, Signature: sigFile}
+	c, err := spec.prepare(context.Background(), newTestGrabber(), "https://example.com/message.txt", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.check(file)
+}
+
+func TestMinisignCheck(t *testing.T) {
+	for name, sig := range map[string]string{"legacy": minisignTestLegacy, "prehashed": minisignTestPrehashed} {
+		if err := checkMinisign(t, minisignTestMessage, sig); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+
+		var verr *verifyError
+		if err := checkMinisign(t, "Hello World?\n", sig); !errors.As(err, &verr) {
+			t.Errorf("%s: a changed file should fail verification, got %v", name, err)
+		}
+		tampered := strings.Replace(sig, "file:message.txt", "file:other.txt", 1)
+		if err := checkMinisign(t, minisignTestMessage, tampered); !errors.As(err, &verr) || !strings.Contains(err.Error(), "trusted comment") {
+			t.Errorf("%s: a changed trusted comment should fail verification, got %v", name, err)
+		}
+	}
+}
+
+func TestMinisignLegacySizeLimit(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "message.txt")
+	sigFile := file + ".minisig"
+	os.WriteFile(sigFile, []byte(minisignTestLegacy), 0644)
+	// Sparse, so the test doesn't write the data
+	f, _ := os.Create(file)
+	f.Truncate(maxLegacySignedSize + 1)
+	f.Close()
+
+	spec := &grabVerify{PublicKey: minisignTestKey, Signature: sigFile}
+	c, err := spec.prepare(context.Background(), newTestGrabber(), "https://example.com/message.txt", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var verr *verifyError
+	if err := c.check(file); !errors.As(err, &verr) || !strings.Contains(err.Error(), "minisign -H") {
+		t.Errorf("a large file with a legacy signature should be refused, got %v", err)
+	}
+}
+
+// Release downloads redirect to signed storage URLs; the signature is still
+// looked up next to the URL that was asked for
+func TestDownloadSignatureBeforeRedirect(t *testing.T) {
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sig") == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="message.txt"`)
+		w.Write([]byte(minisignTestMessage))
+	}))
+	defer storage.Close()
+	releases := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/download/message.txt":
+			http.Redirect(w, r, storage.URL+"/blob/123?sig=abc", http.StatusFound)
+		case "/download/message.txt.minisig":
+			w.Write([]byte(minisignTestPrehashed))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer releases.Close()
+
+	verify := &grabVerify{PublicKey: minisignTestKey}
+	dir := t.TempDir()
+	path, err := newTestGrabber().download(context.Background(), grabRequest{URL: releases.URL + "/download/message.txt", Dir: dir, Verify: verify})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "message.txt" || !slices.Contains(verify.Passed, "minisign signature") {
+		t.Errorf("got %s, passed %v", path, verify.Passed)
+	}
+}
+
+func TestBatchFailsVerifiedVideos(t *testing.T) {
+	q := newBatchQueue(newTestGrabber(), nil, 1)
+	q.verify = &grabVerify{SumsURL: "https://example.com/SHA256SUMS"}
+	if _, err := q.fetch(context.Background(), &batchJob{URL: "https://youtu.be/abc"}); !errors.Is(err, errVideoVerify) {
+		t.Errorf("expected the video to fail verification, got %v", err)
+	}
+}
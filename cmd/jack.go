@@ -14,7 +14,7 @@ var jackCmd = &cobra.Command{
 	Short: "Launches the Brightside Jack AI Terminal Dashboard",
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("🕶️ Brightside Jack booting up...")
-		ui.StartDashboard()
+		ui.StartDashboard(newTUINewsSource())
 	},
 }
 
@@ -1,178 +1,241 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/mmcdole/gofeed"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
-// News source file (stored in user's config dir)
-var newsConfigFile = filepath.Join(os.Getenv("HOME"), ".brightside_news.json")
-
-// Default news sources (used if no config exists)
-var defaultNewsSources = map[string][]string{
-	"Tech": {
-		"https://www.theverge.com/rss/index.xml",
-		"https://www.wired.com/feed/rss",
-		"https://www.techradar.com/rss",
-		"https://rss.nytimes.com/services/xml/rss/nyt/Technology.xml",
-	},
-	"World": {
-		"http://feeds.bbci.co.uk/news/world/rss.xml",
-		"https://rss.nytimes.com/services/xml/rss/nyt/World.xml",
-		"https://www.aljazeera.com/xml/rss/all.xml",
-	},
-	"Hacker": {
-		"https://news.ycombinator.com/rss",
-	},
-}
-
-// Colors for terminal output
-const (
-	Blue   = "\033[1;34m"
-	Cyan   = "\033[1;36m"
-	Green  = "\033[1;32m"
-	Gray   = "\033[1;30m"
-	Reset  = "\033[0m"
+// Colors for terminal output (cleared when color is disabled)
+var (
+	Blue  = "\033[1;34m"
+	Cyan  = "\033[1;36m"
+	Green = "\033[1;32m"
+	Gray  = "\033[1;30m"
+	Reset = "\033[0m"
 )
 
-// Load user-defined news sources or default ones
-func loadNewsSources() map[string][]string {
-	data, err := os.ReadFile(newsConfigFile)
-	if err != nil {
-		// If no custom sources exist, use default
-		return defaultNewsSources
-	}
-	var sources map[string][]string
-	if err := json.Unmarshal(data, &sources); err != nil {
-		return defaultNewsSources
-	}
-	return sources
-}
-
-// Save user-defined news sources
-func saveNewsSources(sources map[string][]string) {
-	data, err := json.MarshalIndent(sources, "", "  ")
-	if err != nil {
-		fmt.Println("❌ Failed to save news sources!")
-		return
-	}
-	_ = os.WriteFile(newsConfigFile, data, 0644)
-}
-
 // Fetch latest news from RSS feeds
 func fetchNews(category string, limit int) {
-	sources := loadNewsSources()
+	cfg := loadNewsConfigOrDefaults()
+	sources := cfg.sourceMap()
 
 	feeds, exists := sources[category]
 	if !exists {
 		fmt.Println("❌ Invalid category. Available categories:")
-		for k := range sources {
-			fmt.Printf(Green+" - %s\n"+Reset, k)
-		}
+		printCategories(sources)
+		return
+	}
+
+	filters, err := cfg.filtersFor(category, newsGrep)
+	if err != nil {
+		fmt.Println("❌", err)
 		return
 	}
 
-	// Fancy loading effect
-	fmt.Printf("\n📡 Fetching %s news ", category)
-	for i := 0; i < 3; i++ {
-		fmt.Print(".")
-		time.Sleep(500 * time.Millisecond)
+	status := newsStatusWriter(newsOutput)
+	if newsOffline {
+		fmt.Fprintf(status, "\n📦 Loading %s news from cache...\n\n", category)
+	} else {
+		fmt.Fprintf(status, "\n📡 Fetching %s news from %d feeds...\n\n", category, len(feeds))
 	}
-	fmt.Println("\n")
 
-	fp := gofeed.NewParser()
+	results := newNewsFetcher().fetchAll(feeds)
+	seen := loadSeenStore()
+	if err := archiveResults(category, results); err != nil {
+		fmt.Fprintln(status, "⚠️ Could not archive items:", err)
+	}
 
-	for _, feedURL := range feeds {
-		feed, err := fp.ParseURL(feedURL)
-		if err != nil {
-			fmt.Printf("⚠️ Error fetching %s: %v\n", feedURL, err)
+	var cutoff time.Time
+	if newsSince > 0 {
+		cutoff = time.Now().Add(-time.Duration(newsSince))
+	}
+
+	var failed []feedResult
+	var sections []newsSection
+	var all []newsItem
+	for _, res := range results {
+		if res.Err != nil {
+			failed = append(failed, res)
 			continue
 		}
 
-		fmt.Printf(Blue+"📰 %s\n"+Reset, feed.Title)
-
-		count := 0
-		for _, item := range feed.Items {
-			if count >= limit {
-				break
+		var items []newsItem
+		for _, item := range res.Feed.Items {
+			if newsOnlyNew && seen.has(item) {
+				continue
+			}
+			ni := newsItemFrom(category, res.URL, res.Feed, item)
+			if !cutoff.IsZero() && ni.Published != nil && ni.Published.Before(cutoff) {
+				continue
+			}
+			if !filters.allows(ni) {
+				continue
 			}
-			fmt.Printf(Green+"  🔹 %s "+Cyan+"(%s)\n"+Reset, item.Title, item.Link)
-			count++
+			ni.Highlight = filters.highlightFor(ni)
+			if newsSummary {
+				ni.Summary = summarize(ni, newsSummaryChars, newsSummarySentences)
+			}
+			items = append(items, ni)
 		}
-		fmt.Println(Gray + "-------------------------------------------------" + Reset)
+
+		if newsMerge {
+			all = append(all, items...)
+			continue
+		}
+		// Hide feeds whose items were all filtered out
+		if len(items) == 0 && len(res.Feed.Items) > 0 {
+			continue
+		}
+		sections = append(sections, newsSection{Title: res.Feed.Title, URL: res.URL, Cached: res.Cached, Items: items})
 	}
-}
 
-// Add a news source
-func addNewsSource(category, url string) {
-	sources := loadNewsSources()
-	sources[category] = append(sources[category], url)
-	saveNewsSources(sources)
-	fmt.Println(Green + "✅ Source added successfully!" + Reset)
-}
+	if newsMerge {
+		merged := mergeNewsItems(all)
+		if len(merged) > 0 {
+			title := fmt.Sprintf("%s (merged from %d feeds)", category, len(results)-len(failed))
+			sections = append(sections, newsSection{Title: title, Items: merged})
+		}
+	}
 
-// Remove a news source
-func removeNewsSource(category, url string) {
-	sources := loadNewsSources()
-	if _, exists := sources[category]; !exists {
-		fmt.Println("❌ Category not found!")
-		return
+	// Scores have to be known before the limit is applied
+	if newsSort == "score" {
+		enrichSections(sections, newsEnrich && !newsOffline)
+		sortSectionsByScore(sections)
+	} else if newsSort == "date" {
+		for _, s := range sections {
+			sortNewsItems(s.Items)
+		}
+	}
+	for i := range sections {
+		if len(sections[i].Items) > limit {
+			sections[i].Items = sections[i].Items[:limit]
+		}
+	}
+	if newsSort != "score" {
+		enrichSections(sections, newsEnrich && !newsOffline)
+	}
+
+	shown := 0
+	for _, s := range sections {
+		for _, item := range s.Items {
+			if newsMarkShown {
+				for _, raw := range item.rawItems() {
+					seen.mark(raw)
+				}
+			}
+			shown++
+		}
 	}
 
-	newFeeds := []string{}
-	for _, feed := range sources[category] {
-		if feed != url {
-			newFeeds = append(newFeeds, feed)
+	if err := renderNews(os.Stdout, sections, newsRenderOptions{Format: newsOutput, Wide: newsWide}); err != nil {
+		fmt.Fprintln(status, "❌ Failed to write output:", err)
+	}
+	if shown > 0 {
+		if err := saveLastListing(sections); err != nil {
+			fmt.Fprintln(status, "⚠️ Could not save listing for 'news read':", err)
 		}
 	}
 
-	sources[category] = newFeeds
-	saveNewsSources(sources)
-	fmt.Println(Green + "✅ Source removed successfully!" + Reset)
+	if newsOnlyNew && shown == 0 {
+		fmt.Fprintln(status, Green+"✅ No unread items in "+category+Reset)
+	}
+	if newsMarkShown && shown > 0 {
+		if err := seen.save(); err != nil {
+			fmt.Fprintln(status, "❌ Failed to save read items:", err)
+		}
+	}
+
+	printFeedErrors(status, failed, len(results))
+}
+
+// Print a summary of the feeds that failed or timed out
+func printFeedErrors(w io.Writer, failed []feedResult, total int) {
+	if len(failed) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n⚠️ %d of %d feeds failed:\n", len(failed), total)
+	for _, res := range failed {
+		fmt.Fprintf(w, "  - %s: %v\n", res.URL, res.Err)
+	}
 }
 
 // News command
 var limit int
+var newsWorkers int
+var newsTimeout time.Duration
+var newsCacheTTL time.Duration
+var newsOffline bool
+var newsNoCache bool
+var newsOnlyNew bool
+var newsMarkShown bool
+var newsOutput string
+var newsMerge bool
+var newsSince ageFlag
+var newsGrep string
+var newsSort string
+var newsEnrich bool
+var newsTUI bool
+var newsSummary bool
+var newsSummaryChars int
+var newsSummarySentences int
+var newsWide bool
 
 var newsCmd = &cobra.Command{
 	Use:   "news [category]",
 	Short: "Fetch latest news from RSS feeds",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		if newsTUI {
+			browseNews(args)
+			return
+		}
+		if len(args) == 0 {
+			fmt.Println("📚 Available categories:")
+			printCategories(loadNewsSources())
+			return
+		}
+		if newsSort != "feed" && newsSort != "date" && newsSort != "score" {
+			fmt.Println("❌ Unsupported sort order! Use feed, date or score.")
+			return
+		}
+		if !validNewsOutput(newsOutput) {
+			fmt.Printf("❌ Unsupported output format! Use %s.\n", strings.Join(newsOutputFormats, ", "))
+			return
+		}
 		fetchNews(args[0], limit)
 	},
 }
 
-
-// Add source command
-var newsAddCmd = &cobra.Command{
-	Use:   "news-add [category] [url]",
-	Short: "Add a news source to a category",
-	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		addNewsSource(args[0], args[1])
-	},
-}
-
-// Remove source command
-var newsRemoveCmd = &cobra.Command{
-	Use:   "news-remove [category] [url]",
-	Short: "Remove a news source from a category",
-	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		removeNewsSource(args[0], args[1])
-	},
-}
-
 func init() {
+	// color.NoColor is set when stdout is not a terminal or NO_COLOR is set
+	if color.NoColor {
+		disableColors()
+	}
+
 	rootCmd.AddCommand(newsCmd)
 	newsCmd.Flags().IntVarP(&limit, "limit", "l", 5, "Number of articles to fetch (default: 5)")
-	rootCmd.AddCommand(newsAddCmd)
-	rootCmd.AddCommand(newsRemoveCmd)
-}
\ No newline at end of file
+	newsCmd.Flags().BoolVar(&newsOnlyNew, "new", false, "Only show items that have not been read yet")
+	newsCmd.Flags().BoolVar(&newsMarkShown, "mark-read", false, "Mark the items shown as read")
+	newsCmd.Flags().BoolVarP(&newsMerge, "merge", "m", false, "Merge all feeds into one deduplicated timeline")
+	newsCmd.Flags().Var(&newsSince, "since", "Only show items newer than this (e.g. 24h, 7d)")
+	newsCmd.Flags().StringVarP(&newsGrep, "grep", "g", "", "Only show items whose title, description or author match this regex")
+	newsCmd.Flags().StringVarP(&newsSort, "sort", "s", "feed", "Item order (feed, date, score)")
+	newsCmd.Flags().BoolVar(&newsEnrich, "enrich", true, "Look up scores and comment counts for Hacker News and Reddit items")
+	newsCmd.Flags().BoolVar(&newsSummary, "summary", false, "Show a plain-text summary of each item")
+	newsCmd.Flags().IntVar(&newsSummaryChars, "summary-chars", 200, "Maximum characters of each summary")
+	newsCmd.Flags().IntVar(&newsSummarySentences, "summary-sentences", 0, "Cut summaries after this many sentences instead of characters")
+	newsCmd.Flags().BoolVar(&newsWide, "wide", false, "Show the author, date and age of each item")
+	newsCmd.Flags().BoolVar(&newsTUI, "tui", false, "Browse the news interactively")
+	newsCmd.Flags().StringVarP(&newsOutput, "output", "o", "text", "Output format (text, json, ndjson, csv, markdown)")
+	newsCmd.PersistentFlags().IntVarP(&newsWorkers, "workers", "w", 4, "Number of feeds to fetch in parallel")
+	newsCmd.PersistentFlags().DurationVarP(&newsTimeout, "timeout", "t", 10*time.Second, "Timeout for each feed (0 = no timeout)")
+	newsCmd.PersistentFlags().DurationVar(&newsCacheTTL, "cache-ttl", 15*time.Minute, "Serve cached feeds younger than this without refetching")
+	newsCmd.PersistentFlags().BoolVar(&newsOffline, "offline", false, "Only read feeds from the local cache")
+	newsCmd.PersistentFlags().BoolVar(&newsNoCache, "no-cache", false, "Always fetch feeds and skip the local cache")
+}
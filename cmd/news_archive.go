@@ -0,0 +1,612 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+// An item kept in the local archive after it has left its feed
+type archivedItem struct {
+	Key       string     `json:"key"`
+	Category  string     `json:"category"`
+	Feed      string     `json:"feed"`
+	FeedURL   string     `json:"feed_url"`
+	Title     string     `json:"title"`
+	Link      string     `json:"link"`
+	Author    string     `json:"author,omitempty"`
+	Summary   string     `json:"summary,omitempty"`
+	Content   string     `json:"content,omitempty"`
+	Published *time.Time `json:"published,omitempty"`
+	Updated   *time.Time `json:"updated,omitempty"`
+	FirstSeen time.Time  `json:"first_seen"`
+}
+
+// Archive of every item ever fetched. Items are appended to one JSON Lines
+// file per day in the archive directory, and a later line for an item
+// replaces earlier ones. Each day file has an index next to it with the key
+// and a hash of every line, so fetches can tell what is new without reading
+// the whole archive. Every access holds the archive lock so concurrent
+// fetches append in turn instead of overwriting each other.
+type newsArchive struct {
+	dir     string
+	Items   map[string]*archivedItem // filled by readItems
+	index   map[string]archiveKey    // latest line of each item
+	pending []*archivedItem
+}
+
+// An index line: which item a day file line holds
+type archiveKey struct {
+	Key       string    `json:"key"`
+	FirstSeen time.Time `json:"first_seen"`
+	Sum       string    `json:"sum"` // hash of the line
+
+	day string // day file holding the line
+}
+
+// Suffixes of the day files and their indexes
+const (
+	archiveDayExt   = ".jsonl"
+	archiveIndexExt = ".keys"
+)
+
+func newsArchiveDir() string {
+	return filepath.Join(brightsideDataDir(), "news_archive")
+}
+
+// Run fn with the archive index loaded and the archive locked against other
+// processes. fn calls readItems if it needs the items themselves.
+func withNewsArchive(fn func(a *newsArchive) error) error {
+	dir := newsArchiveDir()
+	lock, err := lockFile(dir)
+	if err != nil {
+		return fmt.Errorf("could not lock news archive: %w", err)
+	}
+	defer lock.unlock()
+
+	if err := migrateNewsArchive(dir); err != nil {
+		return fmt.Errorf("could not convert news archive: %w", err)
+	}
+	a := &newsArchive{dir: dir, Items: map[string]*archivedItem{}, index: map[string]archiveKey{}}
+	if err := a.readIndex(); err != nil {
+		return fmt.Errorf("could not read news archive: %w", err)
+	}
+	return fn(a)
+}
+
+// Load the whole archive for reading
+func loadNewsArchive() (*newsArchive, error) {
+	var archive *newsArchive
+	err := withNewsArchive(func(a *newsArchive) error {
+		archive = a
+		return a.readItems()
+	})
+	return archive, err
+}
+
+// Names of the day files, oldest first
+func (a *newsArchive) days() ([]string, error) {
+	entries, err := os.ReadDir(a.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	var days []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == archiveDayExt {
+			days = append(days, entry.Name())
+		}
+	}
+	return days, err
+}
+
+func (a *newsArchive) indexPath(day string) string {
+	return filepath.Join(a.dir, strings.TrimSuffix(day, archiveDayExt)+archiveIndexExt)
+}
+
+// Hash identifying the stored form of an item
+func archiveLineSum(line []byte) string {
+	h := fnv.New64a()
+	h.Write(line)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// Call fn with every line of a JSON Lines file that parses, without its
+// newline. Lines that don't, such as one cut short by a crash, are skipped.
+func scanJSONLines[T any](path string, fn func(line []byte, v *T)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		var v T
+		if len(line) > 0 && json.Unmarshal(line, &v) == nil {
+			fn(line, &v)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Read the day indexes, building the index of a day file that has none
+func (a *newsArchive) readIndex() error {
+	days, err := a.days()
+	if err != nil {
+		return err
+	}
+	for _, day := range days {
+		var keys []archiveKey
+		err := scanJSONLines(a.indexPath(day), func(_ []byte, k *archiveKey) {
+			keys = append(keys, *k)
+		})
+		if errors.Is(err, os.ErrNotExist) {
+			keys, err = a.buildIndex(day)
+		}
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			k.day = day
+			a.index[k.Key] = k
+		}
+	}
+	return nil
+}
+
+// Index a day file from its contents
+func (a *newsArchive) buildIndex(day string) ([]archiveKey, error) {
+	var keys []archiveKey
+	var buf bytes.Buffer
+	err := scanJSONLines(filepath.Join(a.dir, day), func(line []byte, e *archivedItem) {
+		if e.Key == "" {
+			return
+		}
+		k := archiveKey{Key: e.Key, FirstSeen: e.FirstSeen, Sum: archiveLineSum(line)}
+		keys = append(keys, k)
+		data, _ := json.Marshal(k)
+		buf.Write(append(data, '\n'))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, writeFileAtomic(a.indexPath(day), buf.Bytes(), 0644)
+}
+
+// Read every item from the day files
+func (a *newsArchive) readItems() error {
+	days, err := a.days()
+	if err != nil {
+		return err
+	}
+	for _, day := range days {
+		err := scanJSONLines(filepath.Join(a.dir, day), func(_ []byte, e *archivedItem) {
+			if e.Key != "" {
+				a.Items[e.Key] = e
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add or update an item, keeping when it was first seen. Only new or
+// changed items are written out by flush.
+// Items are keyed per feed since some feeds use short, non-unique GUIDs.
+func (a *newsArchive) put(item newsItem) {
+	key := item.FeedURL + " " + itemKey(item.raw)
+	entry := &archivedItem{
+		Key:       key,
+		Category:  item.Category,
+		Feed:      item.Feed,
+		FeedURL:   item.FeedURL,
+		Title:     item.Title,
+		Link:      item.Link,
+		Author:    item.Author,
+		Summary:   htmlToText(item.raw.Description),
+		Content:   htmlToText(item.raw.Content),
+		Published: item.raw.PublishedParsed,
+		Updated:   item.raw.UpdatedParsed,
+		FirstSeen: time.Now(),
+	}
+	if old, ok := a.index[key]; ok {
+		entry.FirstSeen = old.FirstSeen
+		if data, err := json.Marshal(entry); err == nil && archiveLineSum(data) == old.Sum {
+			return
+		}
+	}
+	a.Items[key] = entry
+	a.pending = append(a.pending, entry)
+}
+
+// Append the items added since loading to today's file and its index
+func (a *newsArchive) flush() error {
+	if len(a.pending) == 0 {
+		return nil
+	}
+	day := time.Now().Format("2006-01-02") + archiveDayExt
+	var lines, index bytes.Buffer
+	var keys []archiveKey
+	for _, e := range a.pending {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		lines.Write(append(data, '\n'))
+		k := archiveKey{Key: e.Key, FirstSeen: e.FirstSeen, Sum: archiveLineSum(data), day: day}
+		keys = append(keys, k)
+		data, _ = json.Marshal(k)
+		index.Write(append(data, '\n'))
+	}
+	// The lines go first: an index line without its item would hide the item
+	if err := appendFile(filepath.Join(a.dir, day), lines.Bytes()); err != nil {
+		return err
+	}
+	if err := appendFile(a.indexPath(day), index.Bytes()); err != nil {
+		return err
+	}
+	for _, k := range keys {
+		a.index[k.Key] = k
+	}
+	a.pending = nil
+	return nil
+}
+
+// Remove items, rewriting each day file and its index with only the latest
+// line of the items it still holds. Day files left empty are deleted.
+func (a *newsArchive) remove(keys []string) error {
+	for _, key := range keys {
+		delete(a.Items, key)
+		delete(a.index, key)
+	}
+	days, err := a.days()
+	if err != nil {
+		return err
+	}
+	for _, day := range days {
+		path := filepath.Join(a.dir, day)
+		var lines, index bytes.Buffer
+		kept := map[string]bool{}
+		err := scanJSONLines(path, func(line []byte, e *archivedItem) {
+			k, ok := a.index[e.Key]
+			if !ok || k.day != day || k.Sum != archiveLineSum(line) || kept[e.Key] {
+				return
+			}
+			kept[e.Key] = true
+			lines.Write(append(line, '\n'))
+			data, _ := json.Marshal(k)
+			index.Write(append(data, '\n'))
+		})
+		if err != nil {
+			return err
+		}
+		if lines.Len() == 0 {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			if err := os.Remove(a.indexPath(day)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			continue
+		}
+		if err := writeFileAtomic(path, lines.Bytes(), 0644); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(a.indexPath(day), index.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Move an archive from the old single JSON file into day files, by the day
+// each item was first seen (callers hold the archive lock)
+func migrateNewsArchive(dir string) error {
+	legacy := filepath.Join(filepath.Dir(dir), "news_archive.json")
+	var old struct {
+		Items map[string]*archivedItem `json:"items"`
+	}
+	if err := readJSONFile(legacy, &old); err != nil {
+		return err
+	}
+	if old.Items == nil {
+		return nil
+	}
+	days := map[string]*bytes.Buffer{}
+	for _, e := range old.Items {
+		name := e.FirstSeen.Format("2006-01-02") + archiveDayExt
+		if days[name] == nil {
+			days[name] = &bytes.Buffer{}
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		days[name].Write(append(data, '\n'))
+	}
+	for name, buf := range days {
+		if err := appendFile(filepath.Join(dir, name), buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return os.Remove(legacy)
+}
+
+// Best date for an archived item: published, updated, then first seen
+func (e *archivedItem) date() time.Time {
+	if e.Published != nil {
+		return *e.Published
+	}
+	if e.Updated != nil {
+		return *e.Updated
+	}
+	return e.FirstSeen
+}
+
+// Store every item of the fetched feeds in the archive
+func archiveResults(category string, results []feedResult) error {
+	return withNewsArchive(func(a *newsArchive) error {
+		for _, res := range results {
+			if res.Err != nil {
+				continue
+			}
+			for _, item := range res.Feed.Items {
+				a.put(newsItemFrom(category, res.URL, res.Feed, item))
+			}
+		}
+		return a.flush()
+	})
+}
+
+// Split text into lowercase search terms
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// How much a match in each field counts
+var searchFieldWeights = []struct {
+	weight float64
+	value  func(e *archivedItem) string
+}{
+	{3, func(e *archivedItem) string { return e.Title }},
+	{2, func(e *archivedItem) string { return e.Summary }},
+	{1, func(e *archivedItem) string { return e.Content }},
+	{1, func(e *archivedItem) string { return e.Author + " " + e.Feed }},
+}
+
+// A search hit
+type searchResult struct {
+	item  *archivedItem
+	score float64
+}
+
+// Rank archived items against a query with BM25 over weighted fields.
+// Every query term has to appear somewhere in the item.
+func searchArchive(items []*archivedItem, query string) []searchResult {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	// Weighted term frequencies and document lengths
+	type doc struct {
+		item   *archivedItem
+		tf     map[string]float64
+		length float64
+	}
+	docs := make([]doc, 0, len(items))
+	df := map[string]int{}
+	totalLength := 0.0
+	for _, item := range items {
+		d := doc{item: item, tf: map[string]float64{}}
+		for _, field := range searchFieldWeights {
+			for _, t := range searchTerms(field.value(item)) {
+				d.tf[t] += field.weight
+				d.length += field.weight
+			}
+		}
+		for t := range d.tf {
+			df[t]++
+		}
+		totalLength += d.length
+		docs = append(docs, d)
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	avgLength := totalLength / float64(len(docs))
+
+	const k1, b = 1.2, 0.75
+	var results []searchResult
+	for _, d := range docs {
+		score := 0.0
+		matchedAll := true
+		for _, t := range terms {
+			tf := d.tf[t]
+			if tf == 0 {
+				matchedAll = false
+				break
+			}
+			idf := math.Log(1 + (float64(len(docs))-float64(df[t])+0.5)/(float64(df[t])+0.5))
+			score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*d.length/avgLength))
+		}
+		if matchedAll {
+			results = append(results, searchResult{item: d.item, score: score})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].item.date().After(results[j].item.date())
+	})
+	return results
+}
+
+// Search flags
+var searchCategory string
+var searchSince ageFlag
+var searchLimit int
+
+// Search the archive and print ranked results
+func searchNews(query string) {
+	archive, err := loadNewsArchive()
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+
+	var cutoff time.Time
+	if searchSince > 0 {
+		cutoff = time.Now().Add(-time.Duration(searchSince))
+	}
+	var items []*archivedItem
+	for _, e := range archive.Items {
+		if searchCategory != "" && !strings.EqualFold(e.Category, searchCategory) {
+			continue
+		}
+		if !cutoff.IsZero() && e.date().Before(cutoff) {
+			continue
+		}
+		items = append(items, e)
+	}
+
+	results := searchArchive(items, query)
+	if len(results) == 0 {
+		fmt.Printf("🔍 No archived items match %q\n", query)
+		return
+	}
+	fmt.Printf("🔍 %d items match %q\n\n", len(results), query)
+	if len(results) > searchLimit {
+		results = results[:searchLimit]
+	}
+
+	listing := newsSection{}
+	for i, r := range results {
+		e := r.item
+		fmt.Printf(Green+"  🔹 [%d] %s "+Cyan+"(%s)\n"+Reset, i+1, e.Title, e.Link)
+		fmt.Printf(Gray+"       %s · %s · %s\n"+Reset, e.date().Format("2006-01-02"), e.Feed, e.Category)
+		listing.Items = append(listing.Items, newsItem{Title: e.Title, Link: e.Link, Feed: e.Feed})
+	}
+	if err := saveLastListing([]newsSection{listing}); err != nil {
+		fmt.Println("⚠️ Could not save listing for 'news read':", err)
+	}
+}
+
+// Print archive size and date range
+func showArchiveStats() {
+	archive, err := loadNewsArchive()
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	if len(archive.Items) == 0 {
+		fmt.Println("📦 The news archive is empty.")
+		return
+	}
+
+	oldest, newest := time.Now(), time.Time{}
+	for _, e := range archive.Items {
+		d := e.date()
+		if d.Before(oldest) {
+			oldest = d
+		}
+		if d.After(newest) {
+			newest = d
+		}
+	}
+	fmt.Printf("📦 %d archived items from %s to %s\n", len(archive.Items), oldest.Format("2006-01-02"), newest.Format("2006-01-02"))
+	fmt.Println(Gray + archive.dir + Reset)
+}
+
+// Prune flag
+var pruneOlderThan ageFlag
+
+// Remove archived items older than the given age
+func pruneNewsArchive() {
+	if pruneOlderThan <= 0 {
+		fmt.Println("❌ Use --older-than to say what to prune, e.g. --older-than 90d")
+		return
+	}
+	cutoff := time.Now().Add(-time.Duration(pruneOlderThan))
+	left := 0
+	var removed []string
+	err := withNewsArchive(func(a *newsArchive) error {
+		if err := a.readItems(); err != nil {
+			return err
+		}
+		for key, e := range a.Items {
+			if e.date().Before(cutoff) {
+				removed = append(removed, key)
+			}
+		}
+		left = len(a.Items) - len(removed)
+		return a.remove(removed)
+	})
+	if err != nil {
+		fmt.Println("❌ Failed to prune news archive:", err)
+		return
+	}
+	fmt.Printf(Green+"✅ Pruned %d items, %d left\n"+Reset, len(removed), left)
+}
+
+var newsSearchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Full-text search of every news item fetched so far",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		searchNews(strings.Join(args, " "))
+	},
+}
+
+var newsArchiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Show or manage the local news archive",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		showArchiveStats()
+	},
+}
+
+var newsArchivePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old items from the news archive",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		pruneNewsArchive()
+	},
+}
+
+func init() {
+	newsSearchCmd.Flags().StringVarP(&searchCategory, "category", "c", "", "Only search this category")
+	newsSearchCmd.Flags().Var(&searchSince, "since", "Only search items newer than this (e.g. 30d)")
+	newsSearchCmd.Flags().IntVarP(&searchLimit, "limit", "l", 20, "Maximum number of results")
+	newsArchivePruneCmd.Flags().Var(&pruneOlderThan, "older-than", "Remove items older than this (e.g. 90d)")
+	newsArchiveCmd.AddCommand(newsArchivePruneCmd)
+	newsCmd.AddCommand(newsSearchCmd)
+	newsCmd.AddCommand(newsArchiveCmd)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func testArchiveResult(feedURL string, titles ...string) []feedResult {
+	feed := &gofeed.Feed{Title: feedURL}
+	for _, title := range titles {
+		feed.Items = append(feed.Items, &gofeed.Item{Title: title, Link: feedURL + "/" + title})
+	}
+	return []feedResult{{URL: feedURL, Feed: feed}}
+}
+
+func TestArchiveConcurrentWrites(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			feedURL := fmt.Sprintf("https://feed%d.example", i)
+			if err := archiveResults("Tech", testArchiveResult(feedURL, "a", "b", "c")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	archive, err := loadNewsArchive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archive.Items) != 24 {
+		t.Errorf("expected 24 archived items, got %d", len(archive.Items))
+	}
+}
+
+func TestArchiveAppendsOnlyChanges(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	archiveResults("Tech", testArchiveResult("https://a.example", "one", "two"))
+	archiveResults("Tech", testArchiveResult("https://a.example", "one", "two"))
+	results := testArchiveResult("https://a.example", "one")
+	results[0].Feed.Items[0].Description = "now with a summary"
+	archiveResults("Tech", results)
+
+	day := filepath.Join(newsArchiveDir(), time.Now().Format("2006-01-02")+archiveDayExt)
+	data, err := os.ReadFile(day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 3 {
+		t.Errorf("expected 3 lines (2 new items, 1 change), got %d", lines)
+	}
+
+	archive, _ := loadNewsArchive()
+	if e := archive.Items["https://a.example https://a.example/one"]; e == nil || e.Summary != "now with a summary" {
+		t.Errorf("the latest line should win: %+v", e)
+	}
+}
+
+func TestArchiveMigratesAndPrunes(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	old := time.Now().AddDate(0, 0, -100)
+	legacy := filepath.Join(brightsideDataDir(), "news_archive.json")
+	err := writeJSONFile(legacy, map[string]any{"items": map[string]*archivedItem{
+		"old": {Key: "old", Title: "Old", Published: &old, FirstSeen: old},
+		"new": {Key: "new", Title: "New", FirstSeen: time.Now()},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := loadNewsArchive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archive.Items) != 2 {
+		t.Fatalf("expected 2 migrated items, got %d", len(archive.Items))
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("the old archive file should be removed after migrating")
+	}
+
+	err = withNewsArchive(func(a *newsArchive) error { return a.remove([]string{"old"}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(newsArchiveDir(), old.Format("2006-01-02")+archiveDayExt)); !os.IsNotExist(err) {
+		t.Error("an emptied day file should be deleted")
+	}
+	archive, _ = loadNewsArchive()
+	if len(archive.Items) != 1 || archive.Items["new"] == nil {
+		t.Errorf("unexpected items after pruning: %v", archive.Items)
+	}
+}
+
+func TestArchiveFetchReadsOnlyTheIndex(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	archiveResults("Tech", testArchiveResult("https://a.example", "one", "two"))
+
+	// A day file written before indexes existed gets one
+	dayIndex := filepath.Join(newsArchiveDir(), time.Now().Format("2006-01-02")+archiveIndexExt)
+	os.Remove(dayIndex)
+
+	err := withNewsArchive(func(a *newsArchive) error {
+		if len(a.Items) != 0 || len(a.index) != 2 {
+			t.Errorf("expected only the index to be loaded, got %d items and %d keys", len(a.Items), len(a.index))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fileExists(dayIndex) {
+		t.Error("the missing index wasn't rebuilt")
+	}
+
+	archiveResults("Tech", testArchiveResult("https://a.example", "one", "two"))
+	data, _ := os.ReadFile(filepath.Join(newsArchiveDir(), time.Now().Format("2006-01-02")+archiveDayExt))
+	if lines := bytes.Count(data, []byte("\n")); lines != 2 {
+		t.Errorf("unchanged items were appended again: %d lines", lines)
+	}
+}
+
+func TestArchiveAppendsAfterTruncatedLine(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	archiveResults("Tech", testArchiveResult("https://a.example", "one"))
+
+	// A crash in the middle of a write
+	day := filepath.Join(newsArchiveDir(), time.Now().Format("2006-01-02")+archiveDayExt)
+	f, _ := os.OpenFile(day, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"key":"https://a.example cut","tit`)
+	f.Close()
+
+	archiveResults("Tech", testArchiveResult("https://a.example", "two"))
+	archive, err := loadNewsArchive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archive.Items) != 2 || archive.Items["https://a.example https://a.example/two"] == nil {
+		t.Errorf("the item appended after the cut line was lost: %v", archive.Items)
+	}
+}
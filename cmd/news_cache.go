@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cached copy of a feed: the raw body plus the validators needed for a conditional GET
+type cachedFeed struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	Body         []byte    `json:"-"`
+	Private      bool      `json:"-"` // fetched with credentials, only readable by the owner
+}
+
+// On-disk feed cache, one metadata file and one body file per feed URL
+type feedCache struct {
+	dir string
+}
+
+// Open the news feed cache
+func newFeedCache() *feedCache {
+	return &feedCache{dir: filepath.Join(brightsideCacheDir(), "news")}
+}
+
+// File name prefix for a feed URL
+func (c *feedCache) key(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16]))
+}
+
+// Load a cached feed, returning nil if there is no usable entry
+func (c *feedCache) load(url string) *cachedFeed {
+	base := c.key(url)
+	meta, err := os.ReadFile(base + ".json")
+	if err != nil {
+		return nil
+	}
+	var entry cachedFeed
+	if err := json.Unmarshal(meta, &entry); err != nil || entry.URL != url {
+		return nil
+	}
+	body, err := os.ReadFile(base + ".body")
+	if err != nil {
+		return nil
+	}
+	entry.Body = body
+	return &entry
+}
+
+// Store a feed in the cache. The body is written before the metadata
+// so a half-written entry is never picked up with a stale body.
+func (c *feedCache) store(entry *cachedFeed) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	perm := os.FileMode(0644)
+	if entry.Private {
+		perm = 0600
+	}
+	base := c.key(entry.URL)
+	if err := writeFileAtomic(base+".body", entry.Body, perm); err != nil {
+		return err
+	}
+	meta, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(base+".json", meta, perm)
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testFeed = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>First</title><link>https://example.com/1</link></item>
+</channel></rss>`
+
+// Feed server that answers conditional requests and counts what it sees
+func newTestFeedServer(t *testing.T) (*httptest.Server, *atomic.Int32, *atomic.Int32) {
+	var requests, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(testFeed))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests, &notModified
+}
+
+func newTestFetcher(t *testing.T, ttl time.Duration) *feedFetcher {
+	return &feedFetcher{
+		client:  &http.Client{},
+		cache:   &feedCache{dir: t.TempDir()},
+		ttl:     ttl,
+		timeout: 5 * time.Second,
+		workers: 1,
+		secrets: &secretStore{},
+		clients: &clientPool{clients: map[string]*http.Client{}},
+	}
+}
+
+func TestFeedCacheConditionalGet(t *testing.T) {
+	srv, requests, notModified := newTestFeedServer(t)
+	f := newTestFetcher(t, 0)
+	fp := newFeedParser()
+
+	first := f.fetch(fp, srv.URL)
+	if first.Err != nil || first.Cached || len(first.Feed.Items) != 1 {
+		t.Fatalf("first fetch: err=%v cached=%v", first.Err, first.Cached)
+	}
+
+	second := f.fetch(fp, srv.URL)
+	if second.Err != nil {
+		t.Fatal(second.Err)
+	}
+	if !second.Cached || notModified.Load() != 1 {
+		t.Errorf("second fetch should revalidate with If-None-Match: cached=%v 304s=%d", second.Cached, notModified.Load())
+	}
+	if second.Feed.Items[0].Title != "First" {
+		t.Errorf("got item %q from the cache", second.Feed.Items[0].Title)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", requests.Load())
+	}
+}
+
+func TestFeedCacheTTL(t *testing.T) {
+	srv, requests, _ := newTestFeedServer(t)
+	f := newTestFetcher(t, time.Hour)
+	fp := newFeedParser()
+
+	f.fetch(fp, srv.URL)
+	res := f.fetch(fp, srv.URL)
+	if res.Err != nil || !res.Cached {
+		t.Fatalf("fresh entry should be served from the cache: err=%v cached=%v", res.Err, res.Cached)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("a fresh cache entry should not be revalidated, got %d requests", requests.Load())
+	}
+}
+
+func TestFeedCacheOffline(t *testing.T) {
+	srv, requests, _ := newTestFeedServer(t)
+	f := newTestFetcher(t, 0)
+	fp := newFeedParser()
+	f.fetch(fp, srv.URL)
+
+	f.offline = true
+	res := f.fetch(fp, srv.URL)
+	if res.Err != nil || !res.Cached || len(res.Feed.Items) != 1 {
+		t.Fatalf("offline fetch: err=%v cached=%v", res.Err, res.Cached)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("offline mode made a request")
+	}
+
+	if res := f.fetch(fp, srv.URL+"/other"); res.Err == nil {
+		t.Error("offline fetch of an uncached feed should fail")
+	}
+}
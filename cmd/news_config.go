@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// News source file (stored in user's config dir)
+var newsConfigFile = filepath.Join(os.Getenv("HOME"), ".brightside_news.json")
+
+// Default news sources (used if no config exists)
+var defaultNewsSources = map[string][]string{
+	"Tech": {
+		"https://www.theverge.com/rss/index.xml",
+		"https://www.wired.com/feed/rss",
+		"https://www.techradar.com/rss",
+		"https://rss.nytimes.com/services/xml/rss/nyt/Technology.xml",
+	},
+	"World": {
+		"http://feeds.bbci.co.uk/news/world/rss.xml",
+		"https://rss.nytimes.com/services/xml/rss/nyt/World.xml",
+		"https://www.aljazeera.com/xml/rss/all.xml",
+	},
+	"Hacker": {
+		"https://news.ycombinator.com/rss",
+	},
+}
+
+// A single feed in a category
+type newsSource struct {
+	URL   string      `json:"url"`
+	Title string      `json:"title,omitempty"`
+	HTTP  *sourceHTTP `json:"http,omitempty"`
+}
+
+// A named group of feeds
+type newsCategory struct {
+	Sources []newsSource `json:"sources"`
+	Filters newsFilters  `json:"filters,omitzero"`
+}
+
+// Contents of the news config file
+type newsConfig struct {
+	Categories map[string]*newsCategory `json:"categories"`
+	Filters    newsFilters              `json:"filters,omitzero"`
+	Podcasts   podcastConfig            `json:"podcasts,omitzero"`
+}
+
+// Build a config from a flat category → URL map (the original file format)
+func newsConfigFromMap(sources map[string][]string) *newsConfig {
+	cfg := &newsConfig{Categories: map[string]*newsCategory{}}
+	for name, urls := range sources {
+		cat := &newsCategory{}
+		for _, u := range urls {
+			cat.Sources = append(cat.Sources, newsSource{URL: u})
+		}
+		cfg.Categories[name] = cat
+	}
+	return cfg
+}
+
+// Load the news config, falling back to the default sources if there is no file.
+// Both the current format and the original flat category → URL map are accepted.
+func loadNewsConfig() (*newsConfig, error) {
+	data, err := os.ReadFile(newsConfigFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return newsConfigFromMap(defaultNewsSources), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", newsConfigFile, err)
+	}
+
+	cfg := &newsConfig{}
+	if _, ok := raw["categories"]; ok && json.Unmarshal(data, cfg) == nil {
+		if cfg.Categories == nil {
+			cfg.Categories = map[string]*newsCategory{}
+		}
+		for name, cat := range cfg.Categories {
+			if cat == nil {
+				cfg.Categories[name] = &newsCategory{}
+			}
+		}
+		return cfg, nil
+	}
+
+	var legacy map[string][]string
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("%s: %w", newsConfigFile, err)
+	}
+	return newsConfigFromMap(legacy), nil
+}
+
+// Save the news config (always in the current format)
+func saveNewsConfig(cfg *newsConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(newsConfigFile, data, 0644)
+}
+
+// Load the news config for reading, using the defaults if it can't be read
+func loadNewsConfigOrDefaults() *newsConfig {
+	cfg, err := loadNewsConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "⚠️ Could not read news sources, using defaults:", err)
+		cfg = newsConfigFromMap(defaultNewsSources)
+	}
+	return cfg
+}
+
+// Load user-defined news sources or default ones, as a category → URL map
+func loadNewsSources() map[string][]string {
+	return loadNewsConfigOrDefaults().sourceMap()
+}
+
+// Sorted category names
+func (c *newsConfig) categoryNames() []string {
+	names := make([]string, 0, len(c.Categories))
+	for name := range c.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Category → URL map of all sources
+func (c *newsConfig) sourceMap() map[string][]string {
+	sources := make(map[string][]string, len(c.Categories))
+	for name, cat := range c.Categories {
+		urls := []string{}
+		for _, src := range cat.Sources {
+			urls = append(urls, src.URL)
+		}
+		sources[name] = urls
+	}
+	return sources
+}
+
+// Find the category and source for a feed URL
+func (c *newsConfig) find(feedURL string) (string, *newsSource) {
+	for _, name := range c.categoryNames() {
+		cat := c.Categories[name]
+		for i := range cat.Sources {
+			if cat.Sources[i].URL == feedURL {
+				return name, &cat.Sources[i]
+			}
+		}
+	}
+	return "", nil
+}
+
+// Add a source to a category, creating the category if needed
+func (c *newsConfig) add(category string, src newsSource) {
+	cat, exists := c.Categories[category]
+	if !exists {
+		cat = &newsCategory{}
+		c.Categories[category] = cat
+	}
+	cat.Sources = append(cat.Sources, src)
+}
+
+// Remove a source from a category, returning it if it was there
+func (c *newsConfig) remove(category, feedURL string) (newsSource, bool) {
+	cat, exists := c.Categories[category]
+	if !exists {
+		return newsSource{}, false
+	}
+	for i, src := range cat.Sources {
+		if src.URL == feedURL {
+			cat.Sources = append(cat.Sources[:i], cat.Sources[i+1:]...)
+			return src, true
+		}
+	}
+	return newsSource{}, false
+}
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Data passed to digest templates
+type digestData struct {
+	Title      string
+	Generated  time.Time
+	Since      time.Duration
+	Categories []digestCategory
+}
+
+// Top stories of one category
+type digestCategory struct {
+	Name  string
+	Items []digestItem
+}
+
+// One story in the digest
+type digestItem struct {
+	Title     string
+	Link      string
+	Feed      string
+	Author    string
+	Sources   []string
+	Published *time.Time
+	Summary   string
+}
+
+// Helpers available in digest templates
+var digestFuncs = map[string]any{
+	"join": strings.Join,
+	"age":  humanDuration,
+	"date": func(layout string, t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(layout)
+	},
+}
+
+// Built-in templates for each format
+var digestTemplates = map[string]string{
+	"md": `# {{.Title}}
+
+_{{.Generated.Format "Monday, January 2 2006"}} · last {{age .Since}}_
+{{range .Categories}}
+## {{.Name}}
+{{range .Items}}
+- **[{{.Title}}]({{.Link}})** — {{join .Sources ", "}}{{with date "15:04" .Published}} · {{.}}{{end}}
+{{- if .Summary}}
+  {{.Summary}}{{end}}
+{{- end}}
+{{end}}`,
+
+	"txt": `{{.Title}}
+{{.Generated.Format "Monday, January 2 2006"}} · last {{age .Since}}
+{{range .Categories}}
+== {{.Name}} ==
+{{range .Items}}
+* {{.Title}}
+  {{.Link}}
+  {{join .Sources ", "}}{{with date "15:04" .Published}} · {{.}}{{end}}
+{{- if .Summary}}
+  {{.Summary}}{{end}}
+{{end}}{{end}}`,
+
+	"html": `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="font-family: system-ui, sans-serif; max-width: 40rem; margin: 2rem auto; color: #222;">
+<h1>{{.Title}}</h1>
+<p style="color: #777;">{{.Generated.Format "Monday, January 2 2006"}} · last {{age .Since}}</p>
+{{range .Categories}}
+<h2>{{.Name}}</h2>
+<ul>
+{{range .Items}}<li style="margin-bottom: 1rem;">
+<a href="{{.Link}}"><strong>{{.Title}}</strong></a><br>
+<small style="color: #777;">{{join .Sources ", "}}{{with date "15:04" .Published}} · {{.}}{{end}}</small>
+{{if .Summary}}<p style="margin: .25rem 0;">{{.Summary}}</p>{{end}}
+</li>
+{{end}}</ul>
+{{end}}
+</body>
+</html>
+`,
+}
+
+// Digest flags
+var digestSince ageFlag = ageFlag(24 * time.Hour)
+var digestFormat string
+var digestOut string
+var digestTop int
+var digestSummaryLength int
+var digestTemplate string
+var digestSMTP string
+var digestSMTPUser string
+var digestSMTPPassEnv string
+var digestFrom string
+var digestTo []string
+
+// Collect the top stories of each category over the digest window
+func buildDigest(cfg *newsConfig, categories []string) digestData {
+	data := digestData{
+		Title:     "brightside news digest",
+		Generated: time.Now(),
+		Since:     time.Duration(digestSince),
+	}
+	cutoff := data.Generated.Add(-data.Since)
+	fetcher := newNewsFetcher()
+
+	for _, name := range categories {
+		results := fetcher.fetchAll(cfg.sourceMap()[name])
+		if err := archiveResults(name, results); err != nil {
+			fmt.Fprintln(os.Stderr, "⚠️ Could not archive items:", err)
+		}
+
+		filters, err := cfg.filtersFor(name, "")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "⚠️", err)
+			filters = &newsFilterSet{}
+		}
+
+		var items []newsItem
+		var failed []feedResult
+		for _, res := range results {
+			if res.Err != nil {
+				failed = append(failed, res)
+				continue
+			}
+			for _, item := range res.Feed.Items {
+				ni := newsItemFrom(name, res.URL, res.Feed, item)
+				if ni.Published != nil && ni.Published.Before(cutoff) {
+					continue
+				}
+				if filters.allows(ni) {
+					items = append(items, ni)
+				}
+			}
+		}
+		printFeedErrors(os.Stderr, failed, len(results))
+
+		merged := mergeNewsItems(items)
+		if len(merged) > digestTop {
+			merged = merged[:digestTop]
+		}
+		if len(merged) == 0 {
+			continue
+		}
+
+		cat := digestCategory{Name: name}
+		for _, item := range merged {
+			cat.Items = append(cat.Items, digestItem{
+				Title:     item.Title,
+				Link:      item.Link,
+				Feed:      item.Feed,
+				Author:    item.Author,
+				Sources:   item.Sources,
+				Published: item.Published,
+				Summary:   truncateRunes(itemSummary(item), digestSummaryLength),
+			})
+		}
+		data.Categories = append(data.Categories, cat)
+	}
+	return data
+}
+
+// Render the digest with the built-in or a custom template
+func renderDigest(w io.Writer, data digestData, format, templatePath string) error {
+	text := digestTemplates[format]
+	if templatePath != "" {
+		custom, err := os.ReadFile(templatePath)
+		if err != nil {
+			return err
+		}
+		text = string(custom)
+	}
+
+	// HTML gets contextual escaping, the other formats are plain text
+	if format == "html" {
+		tmpl, err := htmltemplate.New("digest").Funcs(digestFuncs).Parse(text)
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(w, data)
+	}
+	tmpl, err := template.New("digest").Funcs(digestFuncs).Parse(text)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
+
+// Where and how to email the digest
+type smtpConfig struct {
+	Addr     string // host:port
+	User     string // no authentication when empty
+	Password string
+	From     string
+	To       []string
+}
+
+// SMTP settings from the command line, with the password read from the environment
+func digestSMTPConfig() smtpConfig {
+	return smtpConfig{
+		Addr:     digestSMTP,
+		User:     digestSMTPUser,
+		Password: os.Getenv(digestSMTPPassEnv),
+		From:     digestFrom,
+		To:       digestTo,
+	}
+}
+
+// Send the digest by email over SMTP
+func mailDigest(cfg smtpConfig, subject, format string, body []byte) error {
+	contentType := "text/plain; charset=utf-8"
+	if format == "html" {
+		contentType = "text/html; charset=utf-8"
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s\r\n", contentType)
+	fmt.Fprintf(&msg, "Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.Write(bytes.ReplaceAll(bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n")))
+
+	var auth smtp.Auth
+	if cfg.User != "" {
+		host, _, err := net.SplitHostPort(cfg.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", cfg.User, cfg.Password, host)
+	}
+	return smtp.SendMail(cfg.Addr, auth, cfg.From, cfg.To, msg.Bytes())
+}
+
+// Build the digest and write it out (and mail it if SMTP is configured)
+func runDigest(categories []string) {
+	if _, ok := digestTemplates[digestFormat]; !ok {
+		fmt.Println("❌ Unsupported format! Use md, html or txt.")
+		return
+	}
+	mail := digestSMTPConfig()
+	if mail.Addr != "" && (mail.From == "" || len(mail.To) == 0) {
+		fmt.Println("❌ Sending by email needs --from and --to")
+		return
+	}
+
+	cfg := loadNewsConfigOrDefaults()
+	if len(categories) == 0 {
+		categories = cfg.categoryNames()
+	}
+	for _, name := range categories {
+		if _, exists := cfg.Categories[name]; !exists {
+			fmt.Printf("❌ Invalid category %s. Available categories:\n", name)
+			printCategories(cfg.sourceMap())
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "📡 Building digest for %d categories...\n", len(categories))
+	data := buildDigest(cfg, categories)
+
+	var out bytes.Buffer
+	if err := renderDigest(&out, data, digestFormat, digestTemplate); err != nil {
+		fmt.Println("❌ Failed to render digest:", err)
+		return
+	}
+
+	switch {
+	case digestOut != "":
+		if err := os.WriteFile(digestOut, out.Bytes(), 0644); err != nil {
+			fmt.Println("❌ Failed to write digest:", err)
+			return
+		}
+		fmt.Fprintln(os.Stderr, Green+"✅ Digest saved to "+digestOut+Reset)
+	case mail.Addr == "":
+		os.Stdout.Write(out.Bytes())
+	}
+
+	if mail.Addr != "" {
+		subject := fmt.Sprintf("%s — %s", data.Title, data.Generated.Format("2006-01-02"))
+		if err := mailDigest(mail, subject, digestFormat, out.Bytes()); err != nil {
+			fmt.Println("❌ Failed to send digest:", err)
+			return
+		}
+		fmt.Fprintln(os.Stderr, Green+"✅ Digest sent to "+strings.Join(mail.To, ", ")+Reset)
+	}
+}
+
+var newsDigestCmd = &cobra.Command{
+	Use:   "digest [category...]",
+	Short: "Build a digest of the top stories in every category",
+	Run: func(cmd *cobra.Command, args []string) {
+		runDigest(args)
+	},
+}
+
+func init() {
+	newsDigestCmd.Flags().Var(&digestSince, "since", "Only include items newer than this")
+	newsDigestCmd.Flags().StringVarP(&digestFormat, "format", "f", "md", "Digest format (md, html, txt)")
+	newsDigestCmd.Flags().StringVarP(&digestOut, "out", "o", "", "Write the digest to a file instead of stdout")
+	newsDigestCmd.Flags().IntVarP(&digestTop, "top", "n", 5, "Stories per category")
+	newsDigestCmd.Flags().IntVar(&digestSummaryLength, "summary-length", 280, "Maximum characters of each summary (0 = none)")
+	newsDigestCmd.Flags().StringVar(&digestTemplate, "template", "", "Custom Go template file (html/template for html, text/template otherwise)")
+	newsDigestCmd.Flags().StringVar(&digestSMTP, "smtp", "", "SMTP server (host:port) to email the digest through")
+	newsDigestCmd.Flags().StringVar(&digestSMTPUser, "smtp-user", "", "SMTP username")
+	newsDigestCmd.Flags().StringVar(&digestSMTPPassEnv, "smtp-password-env", "BRIGHTSIDE_SMTP_PASSWORD", "Environment variable holding the SMTP password")
+	newsDigestCmd.Flags().StringVar(&digestFrom, "from", "", "Sender address for the email")
+	newsDigestCmd.Flags().StringSliceVar(&digestTo, "to", nil, "Recipient address (repeatable)")
+	newsCmd.AddCommand(newsDigestCmd)
+}
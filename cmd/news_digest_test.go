@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// What the test SMTP server received
+type receivedMail struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// Accept one SMTP session on localhost and record the envelope and message
+func newTestSMTPServer(t *testing.T) (string, <-chan receivedMail) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	received := make(chan receivedMail, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		var m receivedMail
+		reply("220 localhost ESMTP test")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch {
+			case verb == "EHLO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case strings.HasPrefix(line, "AUTH PLAIN "):
+				decoded, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+				m.auth = string(decoded)
+				reply("235 ok")
+			case strings.HasPrefix(line, "MAIL FROM:"):
+				m.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+				reply("250 ok")
+			case strings.HasPrefix(line, "RCPT TO:"):
+				m.to = append(m.to, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+				reply("250 ok")
+			case verb == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				m.data = data.String()
+				reply("250 queued")
+			case verb == "QUIT":
+				reply("221 bye")
+				received <- m
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return l.Addr().String(), received
+}
+
+func TestMailDigest(t *testing.T) {
+	addr, received := newTestSMTPServer(t)
+	cfg := smtpConfig{
+		Addr:     addr,
+		User:     "me",
+		Password: "secret",
+		From:     "digest@example.com",
+		To:       []string{"a@example.com", "b@example.com"},
+	}
+	body := "<h1>Today</h1>\n<p>Story</p>\n"
+	if err := mailDigest(cfg, "News — today", "html", []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	m := <-received
+
+	if m.auth != "\x00me\x00secret" {
+		t.Errorf("unexpected AUTH PLAIN credentials %q", m.auth)
+	}
+	if m.from != cfg.From || strings.Join(m.to, ",") != "a@example.com,b@example.com" {
+		t.Errorf("unexpected envelope: from %q to %v", m.from, m.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(m.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "News — today" {
+		t.Errorf("subject %q", subject)
+	}
+	if msg.Header.Get("To") != "a@example.com, b@example.com" {
+		t.Errorf("To header %q", msg.Header.Get("To"))
+	}
+	if ct := msg.Header.Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type %q", ct)
+	}
+	got, _ := io.ReadAll(msg.Body)
+	if string(got) != strings.ReplaceAll(body, "\n", "\r\n") {
+		t.Errorf("body %q should have CRLF line endings", got)
+	}
+}
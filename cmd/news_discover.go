@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mmcdole/gofeed"
+)
+
+// Link types that point at a feed
+var feedLinkTypes = []string{
+	"application/rss+xml",
+	"application/atom+xml",
+	"application/feed+json",
+	"application/json",
+}
+
+// Paths commonly used for feeds, probed when a page doesn't advertise one
+var commonFeedPaths = []string{
+	"/feed",
+	"/feed/",
+	"/rss",
+	"/rss.xml",
+	"/atom.xml",
+	"/feed.xml",
+	"/index.xml",
+	"/feed.json",
+}
+
+// A feed found on a website
+type discoveredFeed struct {
+	URL   string
+	Title string
+	Feed  *gofeed.Feed
+}
+
+// Find the feeds a website offers, from its <link rel="alternate"> tags and
+// a few well-known paths. Only candidates that actually parse are returned.
+// The page and every candidate are fetched with the given HTTP options, the
+// ones the feed will be added with.
+func discoverFeeds(pageURL string, opts *sourceHTTP) ([]discoveredFeed, error) {
+	fetcher := newNewsFetcher()
+	fetcher.cache = nil
+	fetcher.offline = false
+	fetcher.options = map[string]*sourceHTTP{pageURL: opts}
+
+	ctx := context.Background()
+	if timeout := opts.timeout(fetcher.timeout); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", newsUserAgent)
+	if err := opts.apply(req, fetcher.secrets); err != nil {
+		return nil, err
+	}
+	req = opts.markHeaders(req)
+	client, err := fetcher.clientFor(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Resolve relative links against where we ended up after redirects
+	base := resp.Request.URL
+
+	var candidates []string
+	titles := map[string]string{}
+	add := func(ref, title string) {
+		u, err := base.Parse(strings.TrimSpace(ref))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		u.Fragment = ""
+		link := u.String()
+		if _, dup := titles[link]; dup {
+			return
+		}
+		titles[link] = title
+		candidates = append(candidates, link)
+	}
+
+	if strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		doc.Find("link[rel~=alternate][href]").Each(func(_ int, s *goquery.Selection) {
+			linkType := strings.ToLower(strings.TrimSpace(s.AttrOr("type", "")))
+			for _, t := range feedLinkTypes {
+				if linkType == t {
+					add(s.AttrOr("href", ""), strings.TrimSpace(s.AttrOr("title", "")))
+					return
+				}
+			}
+		})
+	}
+
+	root := &url.URL{Scheme: base.Scheme, Host: base.Host}
+	for _, path := range commonFeedPaths {
+		add(root.String()+path, "")
+	}
+
+	for _, link := range candidates {
+		fetcher.options[link] = opts
+	}
+
+	var found []discoveredFeed
+	seen := map[string]bool{}
+	for _, res := range fetcher.fetchAll(candidates) {
+		if res.Err != nil {
+			continue
+		}
+		// /feed and /feed/ often serve the same document
+		key := res.Feed.Title + "\x00" + res.Feed.FeedType + "\x00" + firstItemLink(res.Feed)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		title := titles[res.URL]
+		if title == "" {
+			title = res.Feed.Title
+		}
+		found = append(found, discoveredFeed{URL: res.URL, Title: title, Feed: res.Feed})
+	}
+	return found, nil
+}
+
+// Link of the first item in a feed, used to spot duplicate feeds
+func firstItemLink(feed *gofeed.Feed) string {
+	if len(feed.Items) == 0 {
+		return ""
+	}
+	return feed.Items[0].Link
+}
+
+// Let the user pick one of the discovered feeds, or take the first one
+func chooseDiscoveredFeed(found []discoveredFeed, pickFirst bool) (*discoveredFeed, error) {
+	if len(found) == 1 || pickFirst {
+		return &found[0], nil
+	}
+
+	fmt.Println("📡 Found several feeds:")
+	for i, f := range found {
+		fmt.Printf(Green+"  %d) %s "+Cyan+"(%s)\n"+Reset, i+1, f.Title, f.URL)
+	}
+	fmt.Printf("Pick a feed [1-%d]: ", len(found))
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, errors.New("no feed selected")
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || n < 1 || n > len(found) {
+		return nil, errors.New("invalid selection")
+	}
+	return &found[n-1], nil
+}
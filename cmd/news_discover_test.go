@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testFeedPage = `<!doctype html>
+<html><head>
+  <link rel="alternate" type="application/rss+xml" title="Main feed" href="/main.xml#top">
+  <link rel="alternate stylesheet" type="text/css" href="/style.css">
+  <link rel="icon" type="application/rss+xml" href="/not-a-feed.xml">
+  <link rel="alternate" type="application/atom+xml" href="javascript:alert(1)">
+</head><body></body></html>`
+
+// A site that only answers requests carrying its API key
+func newTestFeedSite(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(testFeedPage))
+		case "/main.xml", "/feed", "/feed/":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Write([]byte(testFeed))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDiscoverFeeds(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	srv := newTestFeedSite(t)
+	opts := &sourceHTTP{Headers: map[string]string{"X-Key": "secret"}}
+
+	found, err := discoverFeeds(srv.URL+"/", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// /feed and /feed/ serve the same document as the advertised feed
+	if len(found) != 1 {
+		t.Fatalf("found %d feeds, want 1: %+v", len(found), found)
+	}
+	if found[0].URL != srv.URL+"/main.xml" || found[0].Title != "Main feed" {
+		t.Errorf("unexpected feed %s %q", found[0].URL, found[0].Title)
+	}
+}
+
+func TestDiscoverFeedsWithoutOptions(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	srv := newTestFeedSite(t)
+
+	found, err := discoverFeeds(srv.URL+"/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 0 {
+		t.Errorf("found feeds without the site's API key: %+v", found)
+	}
+}
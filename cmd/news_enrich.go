@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/mmcdole/gofeed/rss"
+)
+
+// Adds site-specific details (score, comment count, discussion link) to an item.
+// When network is false only data already in the feed may be used.
+type itemEnricher interface {
+	enrich(ctx context.Context, client *http.Client, item *newsItem, network bool) error
+}
+
+// Enrichers by host name
+var itemEnrichers = map[string]itemEnricher{}
+
+// Register an enricher for one or more hosts
+func registerEnricher(e itemEnricher, hosts ...string) {
+	for _, host := range hosts {
+		itemEnrichers[host] = e
+	}
+}
+
+func init() {
+	registerEnricher(hackerNewsEnricher{apiBase: "https://hacker-news.firebaseio.com/v0"},
+		"news.ycombinator.com", "hnrss.org")
+	registerEnricher(redditEnricher{},
+		"reddit.com", "www.reddit.com", "old.reddit.com")
+}
+
+// Parser that also keeps the RSS <comments> link, which gofeed drops
+func newFeedParser() *gofeed.Parser {
+	fp := gofeed.NewParser()
+	fp.RSSTranslator = commentsTranslator{}
+	return fp
+}
+
+// RSS translator that copies each item's <comments> URL into Custom["comments"]
+type commentsTranslator struct {
+	gofeed.DefaultRSSTranslator
+}
+
+func (t commentsTranslator) Translate(feed interface{}) (*gofeed.Feed, error) {
+	out, err := t.DefaultRSSTranslator.Translate(feed)
+	if err != nil {
+		return nil, err
+	}
+	rssFeed, ok := feed.(*rss.Feed)
+	if !ok || len(rssFeed.Items) != len(out.Items) {
+		return out, nil
+	}
+	for i, item := range rssFeed.Items {
+		if item.Comments == "" {
+			continue
+		}
+		if out.Items[i].Custom == nil {
+			out.Items[i].Custom = map[string]string{}
+		}
+		out.Items[i].Custom["comments"] = item.Comments
+	}
+	return out, nil
+}
+
+// Find the enricher for an item from its comments link, link or feed URL
+func enricherFor(item newsItem) itemEnricher {
+	candidates := []string{item.Link, item.FeedURL}
+	if item.raw != nil && item.raw.Custom["comments"] != "" {
+		candidates = append([]string{item.raw.Custom["comments"]}, candidates...)
+	}
+	for _, c := range candidates {
+		u, err := url.Parse(c)
+		if err != nil {
+			continue
+		}
+		if e, ok := itemEnrichers[strings.ToLower(u.Hostname())]; ok {
+			return e
+		}
+	}
+	return nil
+}
+
+// Enrich all items of the sections in place, a few at a time
+func enrichSections(sections []newsSection, network bool) {
+	var targets []*newsItem
+	for s := range sections {
+		for i := range sections[s].Items {
+			if enricherFor(sections[s].Items[i]) != nil {
+				targets = append(targets, &sections[s].Items[i])
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	client := &http.Client{Timeout: newsTimeout}
+	jobs := make(chan *newsItem)
+	var wg sync.WaitGroup
+	for w := 0; w < max(1, min(newsWorkers, len(targets))); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				// Enrichment is best-effort; the item is still shown without it
+				_ = enricherFor(*item).enrich(ctx, client, item, network)
+				cancel()
+			}
+		}()
+	}
+	for _, item := range targets {
+		jobs <- item
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// Sort the items of each section by score, highest first
+func sortSectionsByScore(sections []newsSection) {
+	for _, s := range sections {
+		sort.SliceStable(s.Items, func(i, j int) bool {
+			return derefInt(s.Items[i].Score) > derefInt(s.Items[j].Score)
+		})
+	}
+}
+
+// Value of an optional int, -1 if missing
+func derefInt(p *int) int {
+	if p == nil {
+		return -1
+	}
+	return *p
+}
+
+// GET a URL and decode the JSON response
+func getJSON(ctx context.Context, client *http.Client, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", newsUserAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", endpoint, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Hacker News: points and comments from hnrss descriptions or the official API
+type hackerNewsEnricher struct {
+	apiBase string
+}
+
+var (
+	hnItemID     = regexp.MustCompile(`news\.ycombinator\.com/item\?id=(\d+)`)
+	hnrssPoints  = regexp.MustCompile(`Points:\s*(\d+)`)
+	hnrssComment = regexp.MustCompile(`# Comments:\s*(\d+)`)
+)
+
+func (e hackerNewsEnricher) enrich(ctx context.Context, client *http.Client, item *newsItem, network bool) error {
+	// Not every item keeps the feed item it was built from
+	candidates := []string{item.Link}
+	description := ""
+	if item.raw != nil {
+		description = item.raw.Description
+		candidates = []string{item.raw.Custom["comments"], item.Link, description}
+	}
+	var id string
+	for _, s := range candidates {
+		if m := hnItemID.FindStringSubmatch(s); m != nil {
+			id = m[1]
+			break
+		}
+	}
+	if id == "" {
+		return nil
+	}
+	item.DiscussionURL = "https://news.ycombinator.com/item?id=" + id
+
+	// hnrss.org puts the numbers in the description
+	if m := hnrssPoints.FindStringSubmatch(description); m != nil {
+		points, _ := strconv.Atoi(m[1])
+		item.Score = &points
+		if m := hnrssComment.FindStringSubmatch(description); m != nil {
+			comments, _ := strconv.Atoi(m[1])
+			item.Comments = &comments
+		}
+		return nil
+	}
+	if !network {
+		return nil
+	}
+
+	var hn struct {
+		Score       int `json:"score"`
+		Descendants int `json:"descendants"`
+	}
+	if err := getJSON(ctx, client, e.apiBase+"/item/"+id+".json", &hn); err != nil {
+		return err
+	}
+	item.Score, item.Comments = &hn.Score, &hn.Descendants
+	return nil
+}
+
+// Reddit: subreddit from the permalink, score and comments from the public JSON endpoint
+type redditEnricher struct{}
+
+var redditPermalink = regexp.MustCompile(`^/r/([^/]+)/comments/[^/]+`)
+
+func (e redditEnricher) enrich(ctx context.Context, client *http.Client, item *newsItem, network bool) error {
+	u, err := url.Parse(item.Link)
+	if err != nil {
+		return err
+	}
+	m := redditPermalink.FindStringSubmatch(u.Path)
+	if m == nil {
+		return nil
+	}
+	item.Subreddit = m[1]
+	item.DiscussionURL = "https://www.reddit.com" + strings.TrimSuffix(u.Path, "/") + "/"
+	if !network {
+		return nil
+	}
+
+	var listing []struct {
+		Data struct {
+			Children []struct {
+				Data struct {
+					Score       int    `json:"score"`
+					NumComments int    `json:"num_comments"`
+					Subreddit   string `json:"subreddit"`
+					URL         string `json:"url"`
+				} `json:"data"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, client, strings.TrimSuffix(item.DiscussionURL, "/")+".json?limit=1", &listing); err != nil {
+		return err
+	}
+	if len(listing) == 0 || len(listing[0].Data.Children) == 0 {
+		return nil
+	}
+	post := listing[0].Data.Children[0].Data
+	item.Score, item.Comments = &post.Score, &post.NumComments
+	if post.Subreddit != "" {
+		item.Subreddit = post.Subreddit
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func TestHackerNewsEnrichWithoutFeedItem(t *testing.T) {
+	item := &newsItem{Link: "https://news.ycombinator.com/item?id=42"}
+	if err := (hackerNewsEnricher{}).enrich(context.Background(), http.DefaultClient, item, false); err != nil {
+		t.Fatal(err)
+	}
+	if item.DiscussionURL != "https://news.ycombinator.com/item?id=42" {
+		t.Errorf("discussion URL %q should come from the link", item.DiscussionURL)
+	}
+}
+
+func TestHackerNewsEnrichFromHnrss(t *testing.T) {
+	item := &newsItem{
+		Link: "https://example.com/story",
+		raw: &gofeed.Item{
+			Description: "Points: 120</p><p># Comments: 33",
+			Custom:      map[string]string{"comments": "https://news.ycombinator.com/item?id=7"},
+		},
+	}
+	if err := (hackerNewsEnricher{}).enrich(context.Background(), http.DefaultClient, item, false); err != nil {
+		t.Fatal(err)
+	}
+	if item.Score == nil || *item.Score != 120 || item.Comments == nil || *item.Comments != 33 {
+		t.Errorf("score %v comments %v", derefInt(item.Score), derefInt(item.Comments))
+	}
+}
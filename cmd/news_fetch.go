@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// User agent sent with every feed request
+const newsUserAgent = "brightside-go/1.0 (+https://github.com/br1ghts/brightside-go)"
+
+// Result of fetching a single feed
+type feedResult struct {
+	URL    string
+	Feed   *gofeed.Feed
+	Cached bool
+	Err    error
+
+	// Set when the feed was requested over the network
+	Fetched           bool
+	Latency           time.Duration
+	RedirectTo        string
+	PermanentRedirect bool
+}
+
+// Fetches feeds over HTTP, going through the on-disk cache when one is set
+type feedFetcher struct {
+	client  *http.Client
+	cache   *feedCache
+	ttl     time.Duration
+	timeout time.Duration
+	workers int
+	offline bool
+
+	// Per-feed HTTP options from the config, by URL
+	options map[string]*sourceHTTP
+	// Feeds in the config, the only ones whose health is recorded
+	configured map[string]bool
+	secrets    *secretStore
+	clients    *clientPool
+}
+
+// Clients for feeds that need their own proxy or TLS settings
+type clientPool struct {
+	mu      sync.Mutex
+	clients map[string]*http.Client
+}
+
+// Build a fetcher from the news command flags and the per-feed options in the config
+func newNewsFetcher() *feedFetcher {
+	f := &feedFetcher{
+		client:  &http.Client{CheckRedirect: traceRedirect},
+		ttl:     newsCacheTTL,
+		timeout: newsTimeout,
+		workers: newsWorkers,
+		offline: newsOffline,
+		secrets: &secretStore{},
+		clients: &clientPool{clients: map[string]*http.Client{}},
+	}
+	if !newsNoCache || newsOffline {
+		f.cache = newFeedCache()
+	}
+	// Commands report config errors themselves, so a broken file just means no options
+	if cfg, err := loadNewsConfig(); err == nil {
+		f.options = cfg.httpOptions()
+		f.configured = map[string]bool{}
+		for _, urls := range cfg.sourceMap() {
+			for _, u := range urls {
+				f.configured[u] = true
+			}
+		}
+	}
+	return f
+}
+
+// HTTP client for a feed
+func (f *feedFetcher) clientFor(url string) (*http.Client, error) {
+	opts := f.options[url]
+	if !opts.needsTransport() {
+		return f.client, nil
+	}
+
+	f.clients.mu.Lock()
+	defer f.clients.mu.Unlock()
+	if c, ok := f.clients.clients[url]; ok {
+		return c, nil
+	}
+	c, err := opts.client(f.secrets)
+	if err != nil {
+		return nil, err
+	}
+	f.clients.clients[url] = c
+	return c, nil
+}
+
+// Fetch all feeds concurrently using a bounded worker pool.
+// Results come back in the same order as the given URLs.
+func (f *feedFetcher) fetchAll(urls []string) []feedResult {
+	results := make([]feedResult, len(urls))
+	workers := f.workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(urls) {
+		workers = len(urls)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// gofeed parsers keep state while parsing, so each worker gets its own
+			fp := newFeedParser()
+			for i := range jobs {
+				results[i] = f.fetch(fp, urls[i])
+			}
+		}()
+	}
+
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Discovery probes and one-off feeds stay out of the health report
+	var tracked []feedResult
+	for _, res := range results {
+		if f.configured[res.URL] {
+			tracked = append(tracked, res)
+		}
+	}
+	if err := recordFeedHealth(tracked); err != nil {
+		fmt.Fprintln(os.Stderr, "⚠️ Could not save feed health:", err)
+	}
+	return results
+}
+
+// Fetch and parse a single feed. Fresh cache entries are used as-is,
+// stale ones are revalidated with a conditional GET.
+func (f *feedFetcher) fetch(fp *gofeed.Parser, url string) feedResult {
+	res := feedResult{URL: url}
+
+	var cached *cachedFeed
+	if f.cache != nil {
+		cached = f.cache.load(url)
+	}
+
+	if f.offline || (cached != nil && f.ttl > 0 && time.Since(cached.FetchedAt) < f.ttl) {
+		if cached == nil {
+			res.Err = errors.New("not in cache")
+			return res
+		}
+		res.Feed, res.Err = fp.Parse(bytes.NewReader(cached.Body))
+		res.Cached = true
+		return res
+	}
+
+	timeout := f.options[url].timeout(f.timeout)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	trace := &redirectTrace{}
+	ctx = context.WithValue(ctx, redirectTraceKey{}, trace)
+	start := time.Now()
+	entry, notModified, err := f.download(ctx, url, cached)
+	res.Fetched = true
+	res.Latency = time.Since(start)
+	res.RedirectTo, res.PermanentRedirect = trace.final, trace.final != "" && !trace.temporary
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	res.Feed, res.Err = fp.Parse(bytes.NewReader(entry.Body))
+	res.Cached = notModified
+	if res.Err == nil && f.cache != nil {
+		entry.Private = f.options[url].authenticated()
+		if err := f.cache.store(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ Could not cache %s: %v\n", url, err)
+		}
+	}
+	return res
+}
+
+// Download a feed body, sending the cached validators if there are any.
+// A 304 response returns the cached entry with a refreshed timestamp.
+func (f *feedFetcher) download(ctx context.Context, url string, cached *cachedFeed) (*cachedFeed, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("User-Agent", newsUserAgent)
+	if err := f.options[url].apply(req, f.secrets); err != nil {
+		return nil, false, err
+	}
+	req = f.options[url].markHeaders(req)
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	client, err := f.clientFor(url)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		cached.FetchedAt = time.Now()
+		return cached, true, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, false, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	return &cachedFeed{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+		Body:         body,
+	}, false, nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ANSI colors available to highlight rules
+var highlightColors = map[string]string{
+	"red":     "\033[1;31m",
+	"green":   "\033[1;32m",
+	"yellow":  "\033[1;33m",
+	"blue":    "\033[1;34m",
+	"magenta": "\033[1;35m",
+	"cyan":    "\033[1;36m",
+	"white":   "\033[1;37m",
+}
+
+// Item fields a rule can look at
+var newsRuleFields = []string{"title", "description", "author"}
+
+// A keyword or /regex/ matched against some item fields.
+// In JSON it can be a plain string or an object with match/fields/color.
+type newsRule struct {
+	Match  string   `json:"match"`
+	Fields []string `json:"fields,omitempty"`
+	Color  string   `json:"color,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// Include, exclude and highlight rules, set globally or per category
+type newsFilters struct {
+	Include   []newsRule `json:"include,omitempty"`
+	Exclude   []newsRule `json:"exclude,omitempty"`
+	Highlight []newsRule `json:"highlight,omitempty"`
+}
+
+// Accept a bare string as shorthand for {"match": "..."}
+func (r *newsRule) UnmarshalJSON(data []byte) error {
+	var match string
+	if err := json.Unmarshal(data, &match); err == nil {
+		*r = newsRule{Match: match}
+		return nil
+	}
+	type plain newsRule
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+// Compile the rule. "/expr/" is a regular expression, anything else
+// is a case-insensitive keyword.
+func (r *newsRule) compile() error {
+	expr := "(?i)" + regexp.QuoteMeta(r.Match)
+	if len(r.Match) > 2 && strings.HasPrefix(r.Match, "/") && strings.HasSuffix(r.Match, "/") {
+		expr = r.Match[1 : len(r.Match)-1]
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid rule %q: %w", r.Match, err)
+	}
+	for _, f := range r.Fields {
+		if !containsString(newsRuleFields, f) {
+			return fmt.Errorf("invalid rule %q: unknown field %q", r.Match, f)
+		}
+	}
+	if r.Color != "" {
+		if _, ok := highlightColors[r.Color]; !ok {
+			return fmt.Errorf("invalid rule %q: unknown color %q", r.Match, r.Color)
+		}
+	}
+	r.re = re
+	return nil
+}
+
+// Check whether the rule matches an item
+func (r *newsRule) matches(item newsItem) bool {
+	fields := r.Fields
+	if len(fields) == 0 {
+		fields = newsRuleFields
+	}
+	for _, f := range fields {
+		var value string
+		switch f {
+		case "title":
+			value = item.Title
+		case "description":
+			if item.raw != nil {
+				value = item.raw.Description
+			}
+		case "author":
+			value = item.Author
+		}
+		if value != "" && r.re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Combined, compiled rules that apply to one category
+type newsFilterSet struct {
+	include   []newsRule
+	exclude   []newsRule
+	highlight []newsRule
+	grep      *newsRule // must match on top of the include rules
+}
+
+// Build the filter set for a category from the global and category rules,
+// plus an optional ad-hoc --grep pattern
+func (c *newsConfig) filtersFor(category, grep string) (*newsFilterSet, error) {
+	set := &newsFilterSet{}
+	add := func(f newsFilters) {
+		set.include = append(set.include, f.Include...)
+		set.exclude = append(set.exclude, f.Exclude...)
+		set.highlight = append(set.highlight, f.Highlight...)
+	}
+	add(c.Filters)
+	if cat, ok := c.Categories[category]; ok {
+		add(cat.Filters)
+	}
+
+	for _, rules := range [][]newsRule{set.include, set.exclude, set.highlight} {
+		for i := range rules {
+			if err := rules[i].compile(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if grep != "" {
+		re, err := regexp.Compile("(?i)" + grep)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --grep pattern: %w", err)
+		}
+		set.grep = &newsRule{Match: grep, re: re}
+	}
+	return set, nil
+}
+
+// Whether an item passes the include and exclude rules and the --grep pattern
+func (s *newsFilterSet) allows(item newsItem) bool {
+	for i := range s.exclude {
+		if s.exclude[i].matches(item) {
+			return false
+		}
+	}
+	if s.grep != nil && !s.grep.matches(item) {
+		return false
+	}
+	if len(s.include) == 0 {
+		return true
+	}
+	for i := range s.include {
+		if s.include[i].matches(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// Color name of the first highlight rule matching the item, or ""
+func (s *newsFilterSet) highlightFor(item newsItem) string {
+	for i := range s.highlight {
+		if s.highlight[i].matches(item) {
+			if s.highlight[i].Color == "" {
+				return "yellow"
+			}
+			return s.highlight[i].Color
+		}
+	}
+	return ""
+}
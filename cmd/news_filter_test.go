@@ -0,0 +1,42 @@
+package cmd
+
+import "testing"
+
+func TestNewsFiltersGrepNarrows(t *testing.T) {
+	cfg := &newsConfig{Categories: map[string]*newsCategory{
+		"Tech": {Filters: newsFilters{
+			Include: []newsRule{{Match: "go"}, {Match: "rust"}},
+			Exclude: []newsRule{{Match: "/(?i)sponsored/"}},
+		}},
+	}}
+	cases := []struct {
+		grep, title string
+		want        bool
+	}{
+		{"", "Go 1.24 released", true},
+		{"", "Python 4 announced", false},
+		{"", "Sponsored: learn Go", false},
+		{"release", "Go 1.24 released", true},
+		{"release", "Rust 2.0 plans", false},
+		{"release", "Python 4 released", false},
+		{"release", "Sponsored: Go release party", false},
+	}
+	for _, c := range cases {
+		set, err := cfg.filtersFor("Tech", c.grep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := set.allows(newsItem{Title: c.title}); got != c.want {
+			t.Errorf("grep %q, %q: allowed %v, want %v", c.grep, c.title, got, c.want)
+		}
+	}
+
+	// Without include rules --grep alone decides
+	set, _ := cfg.filtersFor("Other", "release")
+	if !set.allows(newsItem{Title: "Python 4 released"}) || set.allows(newsItem{Title: "Python 4 plans"}) {
+		t.Error("--grep should filter categories without include rules")
+	}
+	if _, err := cfg.filtersFor("Tech", "(unclosed"); err == nil {
+		t.Error("an invalid --grep pattern should be an error")
+	}
+}
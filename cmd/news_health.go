@@ -0,0 +1,460 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Number of latency samples kept per feed
+const healthLatencySamples = 10
+
+// Number of item links kept per feed for duplicate detection
+const healthLinkSamples = 50
+
+// Share of identical links above which two feeds count as duplicates
+const duplicateOverlap = 0.8
+
+// Fetch history of one feed
+type feedHealth struct {
+	LastAttempt time.Time `json:"last_attempt"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	LastError   string    `json:"last_error,omitempty"`
+	Failures    int       `json:"consecutive_failures,omitempty"`
+	LatenciesMS []int64   `json:"latencies_ms,omitempty"`
+	Items       int       `json:"items"`
+	ItemsPerDay float64   `json:"items_per_day,omitempty"`
+	NewestItem  time.Time `json:"newest_item,omitzero"`
+	LastNewItem time.Time `json:"last_new_item,omitzero"`
+	RedirectTo  string    `json:"redirect_to,omitempty"`
+	Permanent   bool      `json:"permanent_redirect,omitempty"`
+	Links       []string  `json:"links,omitempty"`
+}
+
+// Fetch history of all feeds, by URL
+type healthStore struct {
+	path  string
+	Feeds map[string]*feedHealth `json:"feeds"`
+}
+
+// Load the feed health store (an empty one if it doesn't exist yet)
+func loadHealthStore() (*healthStore, error) {
+	s := &healthStore{path: filepath.Join(brightsideDataDir(), "news_health.json")}
+	err := readJSONFile(s.path, s)
+	if s.Feeds == nil {
+		s.Feeds = map[string]*feedHealth{}
+	}
+	return s, err
+}
+
+func (s *healthStore) save() error {
+	return writeJSONFile(s.path, s)
+}
+
+// Load the store, let fn update it and save it, holding the store's lock
+// throughout so concurrent fetches don't drop each other's samples
+func withHealthStore(fn func(s *healthStore)) error {
+	path := filepath.Join(brightsideDataDir(), "news_health.json")
+	lock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	s, err := loadHealthStore()
+	if err != nil {
+		return err
+	}
+	fn(s)
+	return s.save()
+}
+
+// Update a feed's history with the outcome of a network fetch
+func (s *healthStore) record(res feedResult) {
+	h, ok := s.Feeds[res.URL]
+	if !ok {
+		h = &feedHealth{}
+		s.Feeds[res.URL] = h
+	}
+	h.LastAttempt = time.Now()
+	h.LatenciesMS = append(h.LatenciesMS, res.Latency.Milliseconds())
+	if len(h.LatenciesMS) > healthLatencySamples {
+		h.LatenciesMS = h.LatenciesMS[len(h.LatenciesMS)-healthLatencySamples:]
+	}
+	h.RedirectTo, h.Permanent = res.RedirectTo, res.PermanentRedirect
+
+	if res.Err != nil {
+		h.Failures++
+		h.LastError = res.Err.Error()
+		return
+	}
+	h.Failures = 0
+	h.LastError = ""
+	h.LastSuccess = h.LastAttempt
+
+	// Item rate and age come from the items' own dates
+	var oldest, newest time.Time
+	known := map[string]bool{}
+	for _, link := range h.Links {
+		known[link] = true
+	}
+	var links []string
+	fresh := false
+	for _, item := range res.Feed.Items {
+		if t := itemTime(newsItemFrom("", res.URL, res.Feed, item)); !t.IsZero() {
+			if oldest.IsZero() || t.Before(oldest) {
+				oldest = t
+			}
+			if t.After(newest) {
+				newest = t
+			}
+		}
+		if item.Link != "" && len(links) < healthLinkSamples {
+			link := canonicalLink(item.Link)
+			links = append(links, link)
+			if len(h.Links) > 0 && !known[link] {
+				fresh = true
+			}
+		}
+	}
+	h.Items = len(res.Feed.Items)
+	h.NewestItem = newest
+	h.ItemsPerDay = 0
+	if days := newest.Sub(oldest).Hours() / 24; h.Items > 1 && days > 0 {
+		h.ItemsPerDay = float64(h.Items-1) / days
+	}
+	if fresh || h.LastNewItem.IsZero() {
+		h.LastNewItem = h.LastAttempt
+	}
+	h.Links = links
+}
+
+// Record the network fetches among the results
+func recordFeedHealth(results []feedResult) error {
+	var fetched []feedResult
+	for _, res := range results {
+		if res.Fetched {
+			fetched = append(fetched, res)
+		}
+	}
+	if len(fetched) == 0 {
+		return nil
+	}
+	return withHealthStore(func(s *healthStore) {
+		for _, res := range fetched {
+			s.record(res)
+		}
+	})
+}
+
+// Average of the recorded latencies
+func (h *feedHealth) avgLatency() time.Duration {
+	if len(h.LatenciesMS) == 0 {
+		return 0
+	}
+	var sum int64
+	for _, ms := range h.LatenciesMS {
+		sum += ms
+	}
+	return time.Duration(sum/int64(len(h.LatenciesMS))) * time.Millisecond
+}
+
+// Share of links two feeds have in common
+func linkOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := map[string]bool{}
+	for _, link := range a {
+		set[link] = true
+	}
+	common := 0
+	for _, link := range b {
+		if set[link] {
+			common++
+		}
+	}
+	return float64(common) / float64(min(len(a), len(b)))
+}
+
+// Remembers where a request was redirected to
+type redirectTrace struct {
+	final     string
+	temporary bool
+}
+
+type redirectTraceKey struct{}
+
+// CheckRedirect hook that records redirects in the request's trace and
+// keeps credentials from following a redirect to another host
+func traceRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	stripSourceHeaders(req, via)
+	if t, ok := req.Context().Value(redirectTraceKey{}).(*redirectTrace); ok {
+		t.final = req.URL.String()
+		if code := req.Response.StatusCode; code != http.StatusMovedPermanently && code != http.StatusPermanentRedirect {
+			t.temporary = true
+		}
+	}
+	return nil
+}
+
+// Doctor flags
+var doctorFix bool
+var doctorNoFetch bool
+var doctorStale ageFlag = ageFlag(30 * 24 * time.Hour)
+var doctorSlow time.Duration
+var doctorFailures int
+
+// A configured feed together with its history
+type doctorFeed struct {
+	category string
+	src      *newsSource
+	health   *feedHealth
+}
+
+// Check the configured feeds and report the ones that need attention
+func newsDoctor() {
+	cfg := loadNewsConfigOrReport()
+	if cfg == nil {
+		return
+	}
+
+	var feeds []doctorFeed
+	var urls []string
+	for _, name := range cfg.categoryNames() {
+		cat := cfg.Categories[name]
+		for i := range cat.Sources {
+			feeds = append(feeds, doctorFeed{category: name, src: &cat.Sources[i]})
+			urls = append(urls, cat.Sources[i].URL)
+		}
+	}
+
+	if !doctorNoFetch {
+		fmt.Printf("📡 Checking %d feeds...\n\n", len(urls))
+		fetcher := newNewsFetcher()
+		fetcher.ttl = 0
+		fetcher.offline = false
+		// fetchAll records the results in the health store
+		fetcher.fetchAll(urls)
+	}
+
+	store, err := loadHealthStore()
+	if err != nil {
+		fmt.Println("❌ Could not read feed health:", err)
+		return
+	}
+	for i := range feeds {
+		feeds[i].health = store.Feeds[feeds[i].src.URL]
+	}
+
+	show := func(icon, title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		fmt.Printf(Blue+"%s %s (%d)\n"+Reset, icon, title, len(lines))
+		for _, line := range lines {
+			fmt.Println("  " + line)
+		}
+		fmt.Println()
+	}
+	problems := 0
+	report := func(icon, title string, lines []string) {
+		problems += len(lines)
+		show(icon, title, lines)
+	}
+
+	var failing, stale, slow, redirected, unknown, duplicates []string
+	var moves []doctorFeed
+	now := time.Now()
+	for _, f := range feeds {
+		h := f.health
+		name := fmt.Sprintf("%s "+Gray+"[%s]"+Reset, f.src.URL, f.category)
+		if h == nil {
+			unknown = append(unknown, name)
+			continue
+		}
+		if h.Failures > 0 && h.Failures >= doctorFailures {
+			since := "never succeeded"
+			if !h.LastSuccess.IsZero() {
+				since = "last success " + humanDuration(now.Sub(h.LastSuccess)) + " ago"
+			}
+			failing = append(failing, fmt.Sprintf("%s: %s "+Gray+"(%d failures in a row, %s)"+Reset, name, h.LastError, h.Failures, since))
+		}
+		if !h.NewestItem.IsZero() && now.Sub(h.NewestItem) > time.Duration(doctorStale) {
+			stale = append(stale, fmt.Sprintf("%s: newest item %s ago", name, humanDuration(now.Sub(h.NewestItem))))
+		}
+		if avg := h.avgLatency(); doctorSlow > 0 && avg > doctorSlow {
+			slow = append(slow, fmt.Sprintf("%s: %s on average", name, avg.Round(10*time.Millisecond)))
+		}
+		if h.RedirectTo != "" && h.RedirectTo != f.src.URL {
+			kind := "temporarily"
+			if h.Permanent {
+				kind = "permanently"
+				moves = append(moves, f)
+			}
+			redirected = append(redirected, fmt.Sprintf("%s: %s moved to %s", name, kind, h.RedirectTo))
+		}
+	}
+	for i := range feeds {
+		for j := i + 1; j < len(feeds); j++ {
+			a, b := feeds[i].health, feeds[j].health
+			if a == nil || b == nil {
+				continue
+			}
+			if overlap := linkOverlap(a.Links, b.Links); overlap >= duplicateOverlap {
+				duplicates = append(duplicates, fmt.Sprintf("%s [%s] and %s [%s]: %.0f%% of items in common",
+					feeds[i].src.URL, feeds[i].category, feeds[j].src.URL, feeds[j].category, overlap*100))
+			}
+		}
+	}
+
+	report("❌", "Failing", failing)
+	report("🕸️", "Stale", stale)
+	report("🐢", "Slow", slow)
+	report("↪️", "Redirected", redirected)
+	report("👯", "Duplicate content", duplicates)
+	// Feeds not fetched yet aren't a problem, just nothing to judge
+	show("❔", "No fetch history", unknown)
+
+	if doctorFix && len(moves) > 0 {
+		// Sources are updated in place first: removing one shifts the others
+		type removal struct{ category, url string }
+		var removals []removal
+		renames := map[string]string{}
+		for _, f := range moves {
+			oldURL, newURL := f.src.URL, f.health.RedirectTo
+			if existing, _ := cfg.find(newURL); existing != "" {
+				removals = append(removals, removal{f.category, oldURL})
+				fmt.Printf("🧹 Removing %s, %s is already in %s\n", oldURL, newURL, existing)
+				continue
+			}
+			renames[oldURL] = newURL
+			f.src.URL = newURL
+			fmt.Printf("🔧 %s → %s\n", oldURL, newURL)
+		}
+		for _, r := range removals {
+			cfg.remove(r.category, r.url)
+		}
+		if !saveNewsConfigOrReport(cfg) {
+			return
+		}
+		// Move the history along with the feeds
+		err := withHealthStore(func(s *healthStore) {
+			for oldURL, newURL := range renames {
+				if h := s.Feeds[oldURL]; h != nil {
+					h.RedirectTo, h.Permanent = "", false
+					s.Feeds[newURL] = h
+				}
+				delete(s.Feeds, oldURL)
+			}
+			for _, r := range removals {
+				delete(s.Feeds, r.url)
+			}
+		})
+		if err != nil {
+			fmt.Println("⚠️ Could not save feed health:", err)
+		}
+		fmt.Println(Green + "✅ Updated permanent redirects" + Reset)
+	} else if len(moves) > 0 {
+		fmt.Println("💡 Run 'news doctor --fix' to update permanent redirects.")
+	}
+
+	if problems == 0 && len(unknown) > 0 {
+		fmt.Println(Green + "✅ No problems in the feeds fetched so far" + Reset)
+		return
+	}
+	if problems == 0 {
+		fmt.Println(Green + "✅ All feeds look healthy!" + Reset)
+		return
+	}
+	if !doctorFix {
+		os.Exit(1)
+	}
+}
+
+// Print the recorded stats of every configured feed
+func printFeedStats() {
+	cfg := loadNewsConfigOrReport()
+	if cfg == nil {
+		return
+	}
+	store, err := loadHealthStore()
+	if err != nil {
+		fmt.Println("❌ Could not read feed health:", err)
+		return
+	}
+
+	now := time.Now()
+	for _, name := range cfg.categoryNames() {
+		fmt.Printf(Blue+"📂 %s\n"+Reset, name)
+		srcs := append([]newsSource(nil), cfg.Categories[name].Sources...)
+		sort.Slice(srcs, func(i, j int) bool { return srcs[i].URL < srcs[j].URL })
+		for _, src := range srcs {
+			h := store.Feeds[src.URL]
+			if h == nil {
+				fmt.Printf("  ❔ %s "+Gray+"(never fetched)\n"+Reset, src.URL)
+				continue
+			}
+			icon := "✅"
+			if h.Failures > 0 {
+				icon = "❌"
+			}
+			fmt.Printf("  %s %s\n", icon, src.URL)
+			stats := []string{fmt.Sprintf("%d items", h.Items)}
+			if h.ItemsPerDay > 0 {
+				stats = append(stats, fmt.Sprintf("%.1f/day", h.ItemsPerDay))
+			}
+			if avg := h.avgLatency(); avg > 0 {
+				stats = append(stats, fmt.Sprintf("%s avg", avg.Round(time.Millisecond)))
+			}
+			if !h.LastSuccess.IsZero() {
+				stats = append(stats, "ok "+humanDuration(now.Sub(h.LastSuccess))+" ago")
+			}
+			if !h.LastNewItem.IsZero() {
+				stats = append(stats, "new item "+humanDuration(now.Sub(h.LastNewItem))+" ago")
+			}
+			if h.Failures > 0 {
+				stats = append(stats, fmt.Sprintf("%d failures: %s", h.Failures, h.LastError))
+			}
+			fmt.Printf(Gray+"     %s\n"+Reset, strings.Join(stats, " · "))
+		}
+	}
+}
+
+var newsDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Find failing, stale, slow, moved and duplicate feeds",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		newsDoctor()
+	},
+}
+
+var newsStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the fetch history of every feed",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		printFeedStats()
+	},
+}
+
+func init() {
+	newsDoctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Update permanently redirected feeds in the config")
+	newsDoctorCmd.Flags().BoolVar(&doctorNoFetch, "no-fetch", false, "Only use the recorded history, don't fetch the feeds now")
+	newsDoctorCmd.Flags().Var(&doctorStale, "stale", "Report feeds whose newest item is older than this")
+	newsDoctorCmd.Flags().DurationVar(&doctorSlow, "slow", 3*time.Second, "Report feeds slower than this on average")
+	newsDoctorCmd.Flags().IntVar(&doctorFailures, "failures", 1, "Report feeds that failed this many times in a row")
+	newsCmd.AddCommand(newsDoctorCmd)
+	newsCmd.AddCommand(newsStatsCmd)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func TestRecordFeedHealthConcurrently(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := feedResult{
+				URL:     fmt.Sprintf("https://feed%d.example", i),
+				Feed:    &gofeed.Feed{},
+				Fetched: true,
+				Latency: 50 * time.Millisecond,
+			}
+			if err := recordFeedHealth([]feedResult{res}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	store, err := loadHealthStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Feeds) != 8 {
+		t.Errorf("expected history for 8 feeds, got %d", len(store.Feeds))
+	}
+}
+
+func TestFetchAllRecordsConfiguredFeedsOnly(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	srv, _, _ := newTestFeedServer(t)
+	f := newTestFetcher(t, 0)
+	f.configured = map[string]bool{srv.URL: true}
+
+	f.fetchAll([]string{srv.URL, srv.URL + "/feed.json"})
+
+	store, err := loadHealthStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Feeds[srv.URL]; !ok || len(store.Feeds) != 1 {
+		t.Errorf("expected history for the configured feed only, got %v", slices.Collect(maps.Keys(store.Feeds)))
+	}
+}
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credential file for feed secrets, referenced from the config as "cred:<name>"
+var newsCredentialsFile = filepath.Join(os.Getenv("HOME"), ".brightside_credentials.json")
+
+// HTTP options for a single feed. Header values, passwords, tokens and the
+// proxy URL may be secret references: "env:NAME" reads an environment variable,
+// "cred:name" reads an entry of the credentials file.
+type sourceHTTP struct {
+	UserAgent string            `json:"user_agent,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Auth      *sourceAuth       `json:"auth,omitempty"`
+	Proxy     string            `json:"proxy,omitempty"`
+	Timeout   string            `json:"timeout,omitempty"`
+	Insecure  bool              `json:"insecure_skip_verify,omitempty"`
+}
+
+// Basic or bearer authentication for a feed
+type sourceAuth struct {
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// Check the options without resolving any secrets
+func (o *sourceHTTP) validate() error {
+	if o.Timeout != "" {
+		if _, err := time.ParseDuration(o.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout %q", o.Timeout)
+		}
+	}
+	if o.Auth != nil {
+		switch o.Auth.Type {
+		case "basic":
+			if o.Auth.Username == "" {
+				return errors.New("basic auth needs a username")
+			}
+		case "bearer":
+			if o.Auth.Token == "" {
+				return errors.New("bearer auth needs a token")
+			}
+		default:
+			return fmt.Errorf("unknown auth type %q (use basic or bearer)", o.Auth.Type)
+		}
+	}
+	if o.Proxy != "" && !isSecretRef(o.Proxy) {
+		if u, err := url.Parse(o.Proxy); err != nil || u.Host == "" {
+			return fmt.Errorf("invalid proxy %q", o.Proxy)
+		}
+	}
+	return nil
+}
+
+// Per-feed timeout, or the fallback if none is set
+func (o *sourceHTTP) timeout(fallback time.Duration) time.Duration {
+	if o == nil || o.Timeout == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(o.Timeout)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+// Add the user agent, headers and authentication to a request
+func (o *sourceHTTP) apply(req *http.Request, secrets *secretStore) error {
+	if o == nil {
+		return nil
+	}
+	if o.UserAgent != "" {
+		req.Header.Set("User-Agent", o.UserAgent)
+	}
+	for name, value := range o.Headers {
+		v, err := secrets.resolve(value)
+		if err != nil {
+			return fmt.Errorf("header %s: %w", name, err)
+		}
+		req.Header.Set(name, v)
+	}
+	if o.Auth == nil {
+		return nil
+	}
+	switch o.Auth.Type {
+	case "basic":
+		password, err := secrets.resolve(o.Auth.Password)
+		if err != nil {
+			return fmt.Errorf("password: %w", err)
+		}
+		req.SetBasicAuth(o.Auth.Username, password)
+	case "bearer":
+		token, err := secrets.resolve(o.Auth.Token)
+		if err != nil {
+			return fmt.Errorf("token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// Whether requests carry credentials, so responses may be private
+func (o *sourceHTTP) authenticated() bool {
+	return o != nil && (len(o.Headers) > 0 || o.Auth != nil)
+}
+
+// Context key for the names of the credential headers set on a request
+type sourceHeadersKey struct{}
+
+// Attach the names of the configured headers so a redirect can drop them
+func (o *sourceHTTP) markHeaders(req *http.Request) *http.Request {
+	if !o.authenticated() {
+		return req
+	}
+	names := make([]string, 0, len(o.Headers)+1)
+	for name := range o.Headers {
+		names = append(names, name)
+	}
+	if o.Auth != nil {
+		names = append(names, "Authorization")
+	}
+	return req.WithContext(context.WithValue(req.Context(), sourceHeadersKey{}, names))
+}
+
+// Drop the configured headers and authentication when a redirect leaves the
+// feed's host. Go keeps custom headers on every redirect, and Authorization
+// on any other port or subdomain, while they may hold API keys for this host only.
+func stripSourceHeaders(req *http.Request, via []*http.Request) {
+	names, ok := req.Context().Value(sourceHeadersKey{}).([]string)
+	if !ok || len(via) == 0 || req.URL.Host == via[0].URL.Host {
+		return
+	}
+	for _, name := range names {
+		req.Header.Del(name)
+	}
+}
+
+// Whether the options need their own transport
+func (o *sourceHTTP) needsTransport() bool {
+	return o != nil && (o.Proxy != "" || o.Insecure)
+}
+
+// Build a client with the proxy and TLS settings of the options
+func (o *sourceHTTP) client(secrets *secretStore) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.Proxy != "" {
+		proxy, err := secrets.resolve(o.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("proxy: %w", err)
+		}
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	if o.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &http.Client{Transport: transport, CheckRedirect: traceRedirect}, nil
+}
+
+// Whether a value points at a secret instead of holding it
+func isSecretRef(value string) bool {
+	return strings.HasPrefix(value, "env:") || strings.HasPrefix(value, "cred:")
+}
+
+// Resolves secret references, reading the credentials file once when first needed
+type secretStore struct {
+	once  sync.Once
+	creds map[string]string
+	err   error
+}
+
+func (s *secretStore) resolve(value string) (string, error) {
+	if name, ok := strings.CutPrefix(value, "env:"); ok {
+		v, set := os.LookupEnv(name)
+		if !set {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+	}
+	if name, ok := strings.CutPrefix(value, "cred:"); ok {
+		s.once.Do(func() { s.creds, s.err = loadNewsCredentials() })
+		if s.err != nil {
+			return "", s.err
+		}
+		v, found := s.creds[name]
+		if !found {
+			return "", fmt.Errorf("no credential %q in %s", name, newsCredentialsFile)
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+// Read the credentials file, refusing it if other users can read it
+func loadNewsCredentials() (map[string]string, error) {
+	info, err := os.Stat(newsCredentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("%s is readable by other users, run: chmod 600 %s", newsCredentialsFile, newsCredentialsFile)
+	}
+	creds := map[string]string{}
+	if err := readJSONFile(newsCredentialsFile, &creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// HTTP options of every source that has them, by URL
+func (c *newsConfig) httpOptions() map[string]*sourceHTTP {
+	options := map[string]*sourceHTTP{}
+	for _, cat := range c.Categories {
+		for _, src := range cat.Sources {
+			if src.HTTP != nil {
+				options[src.URL] = src.HTTP
+			}
+		}
+	}
+	return options
+}
+
+// Parse "Name: value" header flags
+func parseHeaderFlags(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	headers := map[string]string{}
+	for _, h := range values {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header %q (use \"Name: value\")", h)
+		}
+		headers[http.CanonicalHeaderKey(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// HTTP option flags of `news sources add`
+var (
+	sourceUserAgent string
+	sourceHeaders   []string
+	sourceAuthType  string
+	sourceUsername  string
+	sourceSecret    string
+	sourceProxy     string
+	sourceTimeout   time.Duration
+	sourceInsecure  bool
+)
+
+// Build the HTTP options from the add flags (nil if none are set)
+func sourceHTTPFromFlags() (*sourceHTTP, error) {
+	headers, err := parseHeaderFlags(sourceHeaders)
+	if err != nil {
+		return nil, err
+	}
+	opts := &sourceHTTP{
+		UserAgent: sourceUserAgent,
+		Headers:   headers,
+		Proxy:     sourceProxy,
+		Insecure:  sourceInsecure,
+	}
+	if sourceTimeout > 0 {
+		opts.Timeout = sourceTimeout.String()
+	}
+	switch sourceAuthType {
+	case "":
+	case "basic":
+		opts.Auth = &sourceAuth{Type: "basic", Username: sourceUsername, Password: sourceSecret}
+	case "bearer":
+		opts.Auth = &sourceAuth{Type: "bearer", Token: sourceSecret}
+	default:
+		return nil, fmt.Errorf("unknown auth type %q (use basic or bearer)", sourceAuthType)
+	}
+	if sourceSecret != "" && !isSecretRef(sourceSecret) {
+		fmt.Println("⚠️ The secret will be stored in plain text. Use env:NAME or cred:name to keep it out of the config.")
+	}
+
+	if opts.UserAgent == "" && opts.Headers == nil && opts.Auth == nil && opts.Proxy == "" && opts.Timeout == "" && !opts.Insecure {
+		return nil, nil
+	}
+	return opts, opts.validate()
+}
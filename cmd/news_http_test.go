@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestRedirectDropsCustomHeaders(t *testing.T) {
+	var otherKey, otherAuth string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherKey, otherAuth = r.Header.Get("X-Api-Key"), r.Header.Get("Authorization")
+		w.Write([]byte(testFeed))
+	}))
+	defer other.Close()
+
+	var originKey string
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		originKey = r.Header.Get("X-Api-Key")
+		http.Redirect(w, r, other.URL+"/feed", http.StatusFound)
+	}))
+	defer origin.Close()
+
+	f := newTestFetcher(t, 0)
+	f.client.CheckRedirect = traceRedirect
+	f.options = map[string]*sourceHTTP{origin.URL: {
+		Headers: map[string]string{"X-Api-Key": "s3cret"},
+		Auth:    &sourceAuth{Type: "bearer", Token: "t0ken"},
+	}}
+
+	res := f.fetch(newFeedParser(), origin.URL)
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	if originKey != "s3cret" {
+		t.Errorf("the feed's own host should get the header, got %q", originKey)
+	}
+	if otherKey != "" || otherAuth != "" {
+		t.Errorf("credentials followed the redirect: X-Api-Key=%q Authorization=%q", otherKey, otherAuth)
+	}
+
+	info, err := os.Stat(f.cache.key(origin.URL) + ".body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("cached body of an authenticated feed has mode %o, want 600", perm)
+	}
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// Query parameters that only track where a click came from
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "yclid": true,
+	"mc_cid": true, "mc_eid": true, "igshid": true, "ref": true, "ref_src": true,
+	"ref_url": true, "cmpid": true, "ocid": true, "smid": true, "src": true,
+	"share": true, "taid": true, "ito": true, "_hsenc": true, "_hsmi": true,
+}
+
+// Minimum word overlap for two titles to count as the same story
+const titleSimilarity = 0.75
+
+// Words too common to say anything about a headline
+var titleStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true,
+	"to": true, "in": true, "on": true, "for": true, "at": true, "by": true,
+	"with": true, "is": true, "are": true, "as": true, "from": true, "after": true,
+}
+
+// Duration flag that also accepts days and weeks, e.g. "36h", "7d" or "2w"
+type ageFlag time.Duration
+
+func (a *ageFlag) String() string {
+	if *a == 0 {
+		return "0"
+	}
+	return time.Duration(*a).String()
+}
+
+func (a *ageFlag) Set(s string) error {
+	d, err := parseAge(s)
+	if err != nil {
+		return err
+	}
+	*a = ageFlag(d)
+	return nil
+}
+
+func (a *ageFlag) Type() string {
+	return "duration"
+}
+
+// Parse a duration, with "d" and "w" suffixes for days and weeks
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.ParseFloat(n, 64)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(v * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// Format a duration the way people say it: "36h", "1 day", "2 weeks"
+func humanDuration(d time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case d >= 7*day && d%(7*day) == 0:
+		return plural(int(d/(7*day)), "week")
+	case d >= day && d%day == 0:
+		return plural(int(d/day), "day")
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	}
+	return d.Round(time.Minute).String()
+}
+
+// "1 day", "3 days"
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// Normalize a link so the same article shared by different outlets compares
+// equal: no scheme, "www." or fragment, and no tracking parameters.
+func canonicalLink(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(link)
+	}
+
+	q := u.Query()
+	for key := range q {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "utm_") || trackingParams[lower] {
+			q.Del(key)
+		}
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+	canonical := host + path
+	if encoded := q.Encode(); encoded != "" {
+		canonical += "?" + encoded
+	}
+	return canonical
+}
+
+// Significant lowercase words of a title
+func titleWords(title string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !titleStopWords[w] {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+// Whether two titles are close enough to be the same story (Jaccard similarity)
+func similarTitles(a, b map[string]bool) bool {
+	if len(a) < 3 || len(b) < 3 {
+		return false
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	return float64(shared)/float64(union) >= titleSimilarity
+}
+
+// Published time of an item, zero if unknown
+func itemTime(item newsItem) time.Time {
+	if item.Published == nil {
+		return time.Time{}
+	}
+	return *item.Published
+}
+
+// Sort items newest first; undated items go last
+func sortNewsItems(items []newsItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return itemTime(items[i]).After(itemTime(items[j]))
+	})
+}
+
+// Merge items from several feeds into one timeline, newest first.
+// Near-duplicates collapse into the newest entry, which lists every source.
+func mergeNewsItems(items []newsItem) []newsItem {
+	sorted := append([]newsItem{}, items...)
+	sortNewsItems(sorted)
+
+	var merged []newsItem
+	var words []map[string]bool
+	byLink := map[string]int{}
+
+	for _, item := range sorted {
+		link := canonicalLink(item.Link)
+		w := titleWords(item.Title)
+
+		match, found := byLink[link]
+		if !found || link == "" {
+			found = false
+			for i := range merged {
+				if similarTitles(w, words[i]) {
+					match, found = i, true
+					break
+				}
+			}
+		}
+
+		if found {
+			dup := &merged[match]
+			dup.also = append(dup.also, item.raw)
+			if !containsString(dup.Sources, item.Feed) {
+				dup.Sources = append(dup.Sources, item.Feed)
+			}
+			if link != "" {
+				byLink[link] = match
+			}
+			continue
+		}
+
+		item.Sources = []string{item.Feed}
+		merged = append(merged, item)
+		words = append(words, w)
+		if link != "" {
+			byLink[link] = len(merged) - 1
+		}
+	}
+	return merged
+}
+
+// Whether a slice contains a string
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// All raw feed items behind a (possibly merged) news item
+func (n newsItem) rawItems() []*gofeed.Item {
+	return append([]*gofeed.Item{n.raw}, n.also...)
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func TestCanonicalLink(t *testing.T) {
+	cases := []struct{ link, want string }{
+		{"https://www.example.com/story/", "example.com/story"},
+		{"http://example.com/story#comments", "example.com/story"},
+		{"https://example.com/story?utm_source=rss&utm_medium=feed", "example.com/story"},
+		{"https://example.com/story?fbclid=abc&id=7&ref=hn", "example.com/story?id=7"},
+		{"https://EXAMPLE.com/Story?UTM_Campaign=x", "example.com/Story"},
+		{"not a url", "not a url"},
+	}
+	for _, c := range cases {
+		if got := canonicalLink(c.link); got != c.want {
+			t.Errorf("canonicalLink(%q) = %q, want %q", c.link, got, c.want)
+		}
+	}
+}
+
+func TestSimilarTitles(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		// "released" and "release" differ: 4 shared of 6 words
+		{"Go 1.24 is released today", "The Go 1.24 release is today", false},
+		// Stop words don't count: 6 of 6
+		{"Go 1.24 released with new features", "Go 1.24 released with the new features", true},
+		// 3 shared of 4 words is exactly the threshold
+		{"Rust compiler gets faster", "Rust compiler faster", true},
+		// 3 shared of 5 words is below it
+		{"Rust compiler gets faster", "Rust compiler faster builds", false},
+		// Titles need at least 3 significant words
+		{"Go released", "Go released", false},
+		{"The big news", "The big news", false},
+	}
+	for _, c := range cases {
+		if got := similarTitles(titleWords(c.a), titleWords(c.b)); got != c.want {
+			t.Errorf("similarTitles(%q, %q) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func testNewsItem(feed, title, link string, published time.Time) newsItem {
+	return newsItem{Title: title, Link: link, Feed: feed, Published: &published, raw: &gofeed.Item{Title: title, Link: link}}
+}
+
+func TestMergeNewsItems(t *testing.T) {
+	now := time.Now()
+	items := []newsItem{
+		// Same story by title
+		testNewsItem("A", "Go 1.24 released with new features", "https://a.example/go", now.Add(-3*time.Hour)),
+		testNewsItem("B", "Go 1.24 released with the new features", "https://b.example/go124", now.Add(-time.Hour)),
+		// Same story by link, under another title
+		testNewsItem("C", "Something else entirely happened", "https://www.b.example/go124/?utm_source=c", now.Add(-2*time.Hour)),
+		testNewsItem("A", "Unrelated story about databases", "https://a.example/db", now.Add(-4*time.Hour)),
+	}
+
+	merged := mergeNewsItems(items)
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 stories, got %d: %+v", len(merged), merged)
+	}
+	// The newest copy stays, credited to every feed that had the story
+	if merged[0].Feed != "B" || !slices.Equal(merged[0].Sources, []string{"B", "C", "A"}) {
+		t.Errorf("unexpected merged story %q from %s, sources %v", merged[0].Title, merged[0].Feed, merged[0].Sources)
+	}
+	if len(merged[0].rawItems()) != 3 {
+		t.Errorf("the merged story should keep all 3 feed items, got %d", len(merged[0].rawItems()))
+	}
+	if !slices.Equal(merged[1].Sources, []string{"A"}) {
+		t.Errorf("unexpected sources %v for a story from one feed", merged[1].Sources)
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	day := 24 * time.Hour
+	cases := []struct {
+		d    time.Duration
+		want string
+	}{
+		{90 * time.Minute, "1h30m0s"},
+		{36 * time.Hour, "36h"},
+		{day, "1 day"},
+		{3 * day, "3 days"},
+		{7 * day, "1 week"},
+		{14 * day, "2 weeks"},
+		{10 * day, "10 days"},
+	}
+	for _, c := range cases {
+		if got := humanDuration(c.d); got != c.want {
+			t.Errorf("humanDuration(%s) = %q, want %q", c.d, got, c.want)
+		}
+	}
+}
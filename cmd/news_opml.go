@@ -0,0 +1,434 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Category used for feeds that sit at the top level of an OPML file
+const opmlDefaultCategory = "Imported"
+
+// OPML 2.0 document
+type opmlDoc struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlHead struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type opmlBody struct {
+	Outlines []*opmlOutline `xml:"outline"`
+}
+
+// An outline is either a folder (has children) or a feed (has xmlUrl, or
+// url as some readers write it)
+type opmlOutline struct {
+	Text     string         `xml:"text,attr"`
+	Title    string         `xml:"title,attr,omitempty"`
+	Type     string         `xml:"type,attr,omitempty"`
+	XMLURL   string         `xml:"xmlUrl,attr,omitempty"`
+	URL      string         `xml:"url,attr,omitempty"`
+	HTMLURL  string         `xml:"htmlUrl,attr,omitempty"`
+	Outlines []*opmlOutline `xml:"outline"`
+}
+
+// Feed URL of an outline, "" for a folder
+func (o *opmlOutline) feedURL() string {
+	if o.XMLURL != "" {
+		return o.XMLURL
+	}
+	return o.URL
+}
+
+// Display name of an outline
+func (o *opmlOutline) name() string {
+	if o.Title != "" {
+		return o.Title
+	}
+	return o.Text
+}
+
+// Parse an OPML file into a news config. Nested folders become
+// categories named after their path, e.g. "Tech/Linux". Feeds whose URL
+// `sources add` wouldn't accept are left out and returned as warnings.
+func parseOPML(r io.Reader) (*newsConfig, []string, error) {
+	var doc opmlDoc
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid OPML: %w", err)
+	}
+
+	cfg := &newsConfig{Categories: map[string]*newsCategory{}}
+	var skipped []string
+	var walk func(outlines []*opmlOutline, path []string)
+	walk = func(outlines []*opmlOutline, path []string) {
+		for _, o := range outlines {
+			if o.feedURL() == "" {
+				walk(o.Outlines, append(path, o.name()))
+				continue
+			}
+			feedURL := strings.TrimSpace(o.feedURL())
+			if err := checkFeedURL(feedURL); err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s (%s): %v", o.name(), feedURL, err))
+				continue
+			}
+			category := strings.Join(path, "/")
+			if category == "" {
+				category = opmlDefaultCategory
+			}
+			title := o.name()
+			if title == feedURL {
+				// Exports fall back to the URL for feeds without a title
+				title = ""
+			}
+			if _, src := cfg.find(feedURL); src == nil {
+				cfg.add(category, newsSource{URL: feedURL, Title: title})
+			}
+		}
+	}
+	walk(doc.Body.Outlines, nil)
+
+	return cfg, skipped, nil
+}
+
+// Render the news config as OPML. Categories with "/" in their name are nested again.
+func renderOPML(cfg *newsConfig) ([]byte, error) {
+	doc := opmlDoc{
+		Version: "2.0",
+		Head: opmlHead{
+			Title:       "brightside news sources",
+			DateCreated: time.Now().Format(time.RFC1123Z),
+		},
+	}
+
+	folders := map[string]*opmlOutline{}
+	var folder func(path string) *opmlOutline
+	folder = func(path string) *opmlOutline {
+		if o, ok := folders[path]; ok {
+			return o
+		}
+		parts := strings.Split(path, "/")
+		name := parts[len(parts)-1]
+		o := &opmlOutline{Text: name, Title: name}
+		if len(parts) == 1 {
+			doc.Body.Outlines = append(doc.Body.Outlines, o)
+		} else {
+			parent := folder(strings.Join(parts[:len(parts)-1], "/"))
+			parent.Outlines = append(parent.Outlines, o)
+		}
+		folders[path] = o
+		return o
+	}
+
+	cache := newFeedCache()
+	for _, name := range cfg.categoryNames() {
+		parent := folder(name)
+		for _, src := range cfg.Categories[name].Sources {
+			title := src.Title
+			if title == "" {
+				title = cachedFeedTitle(cache, src.URL)
+			}
+			text := title
+			if text == "" {
+				text = src.URL
+			}
+			parent.Outlines = append(parent.Outlines, &opmlOutline{
+				Text:   text,
+				Title:  title,
+				Type:   "rss",
+				XMLURL: src.URL,
+			})
+		}
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// Title of a feed from the cache, if it has been fetched before
+func cachedFeedTitle(cache *feedCache, feedURL string) string {
+	entry := cache.load(feedURL)
+	if entry == nil {
+		return ""
+	}
+	feed, err := newFeedParser().Parse(bytes.NewReader(entry.Body))
+	if err != nil {
+		return ""
+	}
+	return feed.Title
+}
+
+// Copy of a config whose categories can be changed without touching the original.
+// Filters, podcast settings and per-source options carry over.
+func (c *newsConfig) clone() *newsConfig {
+	out := *c
+	out.Categories = map[string]*newsCategory{}
+	for name, cat := range c.Categories {
+		out.Categories[name] = &newsCategory{Sources: append([]newsSource{}, cat.Sources...), Filters: cat.Filters}
+	}
+	return &out
+}
+
+// Merge imported sources into the current config. Sources that already exist
+// stay in their category but pick up the imported title if they have none.
+func mergeNewsConfig(current, imported *newsConfig) *newsConfig {
+	merged := current.clone()
+	for _, name := range imported.categoryNames() {
+		for _, src := range imported.Categories[name].Sources {
+			if _, existing := merged.find(src.URL); existing != nil {
+				if existing.Title == "" {
+					existing.Title = src.Title
+				}
+				continue
+			}
+			merged.add(name, src)
+		}
+	}
+	return merged
+}
+
+// Replace the sources of the current config with the imported ones. Everything
+// OPML can't express is kept: global filters, podcast settings, the filters of
+// categories that are still there and the HTTP options of sources that are.
+func replaceNewsConfig(current, imported *newsConfig) *newsConfig {
+	next := current.clone()
+	next.Categories = map[string]*newsCategory{}
+	for name, cat := range imported.Categories {
+		replaced := &newsCategory{}
+		if old, ok := current.Categories[name]; ok {
+			replaced.Filters = old.Filters
+		}
+		for _, src := range cat.Sources {
+			if _, old := current.find(src.URL); old != nil {
+				src.HTTP = old.HTTP
+			}
+			replaced.Sources = append(replaced.Sources, src)
+		}
+		next.Categories[name] = replaced
+	}
+	return next
+}
+
+// How a setting changed between two configs ("" if it didn't)
+func settingChange[T any](before, after T) string {
+	switch {
+	case reflect.DeepEqual(before, after):
+		return ""
+	case reflect.ValueOf(before).IsZero():
+		return "added"
+	case reflect.ValueOf(after).IsZero():
+		return "removed"
+	}
+	return "changed"
+}
+
+// Print the differences between two configs, returning the number of changes
+func printNewsConfigDiff(before, after *newsConfig) int {
+	names := map[string]bool{}
+	for name := range before.Categories {
+		names[name] = true
+	}
+	for name := range after.Categories {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	sourcesOf := func(cfg *newsConfig, name string) map[string]newsSource {
+		found := map[string]newsSource{}
+		if cat, ok := cfg.Categories[name]; ok {
+			for _, src := range cat.Sources {
+				found[src.URL] = src
+			}
+		}
+		return found
+	}
+
+	changes := 0
+	for _, name := range sorted {
+		old, updated := sourcesOf(before, name), sourcesOf(after, name)
+
+		var lines []string
+		for _, src := range after.categorySources(name) {
+			prev, existed := old[src.URL]
+			switch {
+			case !existed:
+				lines = append(lines, fmt.Sprintf(Green+"  + %s%s\n"+Reset, src.URL, titleSuffix(src.Title)))
+			case prev.Title != src.Title:
+				lines = append(lines, fmt.Sprintf(Cyan+"  ~ %s%s\n"+Reset, src.URL, titleSuffix(src.Title)))
+			}
+		}
+		for _, src := range before.categorySources(name) {
+			if _, kept := updated[src.URL]; !kept {
+				lines = append(lines, fmt.Sprintf("  - %s%s\n", src.URL, titleSuffix(src.Title)))
+			}
+		}
+		if change := settingChange(before.categoryFilters(name), after.categoryFilters(name)); change != "" {
+			lines = append(lines, fmt.Sprintf("  ⚙️  filters %s\n", change))
+		}
+
+		if len(lines) > 0 {
+			fmt.Printf(Blue+"📂 %s\n"+Reset, name)
+			for _, line := range lines {
+				fmt.Print(line)
+			}
+			changes += len(lines)
+		}
+	}
+
+	if change := settingChange(before.Filters, after.Filters); change != "" {
+		fmt.Printf("⚙️  Global filters %s\n", change)
+		changes++
+	}
+	if change := settingChange(before.Podcasts, after.Podcasts); change != "" {
+		fmt.Printf("🎙️ Podcast settings %s\n", change)
+		changes++
+	}
+	return changes
+}
+
+// Sources of a category, or nil if it doesn't exist
+func (c *newsConfig) categorySources(name string) []newsSource {
+	if cat, ok := c.Categories[name]; ok {
+		return cat.Sources
+	}
+	return nil
+}
+
+// Filters of a category, or none if it doesn't exist
+func (c *newsConfig) categoryFilters(name string) newsFilters {
+	if cat, ok := c.Categories[name]; ok {
+		return cat.Filters
+	}
+	return newsFilters{}
+}
+
+// Format an optional title for diff output
+func titleSuffix(title string) string {
+	if title == "" {
+		return ""
+	}
+	return Gray + " (" + title + ")" + Reset
+}
+
+// Import news sources from an OPML file
+func importNewsOPML(path, mode string, dryRun bool) {
+	if mode != "merge" && mode != "replace" {
+		fmt.Println("❌ Unknown import mode. Use merge or replace.")
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Println("❌ Failed to open OPML file:", err)
+		return
+	}
+	defer file.Close()
+
+	imported, skipped, err := parseOPML(file)
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	for _, warning := range skipped {
+		fmt.Println("⚠️ Skipped", warning)
+	}
+
+	current := loadNewsConfigOrReport()
+	if current == nil {
+		return
+	}
+
+	next := replaceNewsConfig(current, imported)
+	if mode == "merge" {
+		next = mergeNewsConfig(current, imported)
+	}
+
+	changes := printNewsConfigDiff(current, next)
+	if changes == 0 {
+		fmt.Println(Green + "✅ Nothing to import, sources are up to date." + Reset)
+		return
+	}
+	if dryRun {
+		fmt.Printf("\n🔍 Dry run: %d changes not saved.\n", changes)
+		return
+	}
+	if !saveNewsConfigOrReport(next) {
+		return
+	}
+	fmt.Printf(Green+"\n✅ Imported %s (%d changes)\n"+Reset, path, changes)
+}
+
+// Export news sources as OPML to a file or stdout
+func exportNewsOPML(out string) {
+	cfg := loadNewsConfigOrReport()
+	if cfg == nil {
+		return
+	}
+
+	data, err := renderOPML(cfg)
+	if err != nil {
+		fmt.Println("❌ Failed to build OPML:", err)
+		return
+	}
+
+	if out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		fmt.Println("❌ Failed to write OPML file:", err)
+		return
+	}
+	fmt.Println(Green+"✅ Exported news sources to"+Reset, out)
+}
+
+// Import/export flags
+var opmlImportMode string
+var opmlDryRun bool
+var opmlOut string
+
+var newsImportCmd = &cobra.Command{
+	Use:   "import [file.opml]",
+	Short: "Import news sources from an OPML file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		importNewsOPML(args[0], opmlImportMode, opmlDryRun)
+	},
+}
+
+var newsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export news sources as OPML",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		exportNewsOPML(opmlOut)
+	},
+}
+
+func init() {
+	newsImportCmd.Flags().StringVarP(&opmlImportMode, "mode", "m", "merge", "How to combine with current sources (merge, replace)")
+	newsImportCmd.Flags().BoolVar(&opmlDryRun, "dry-run", false, "Show what would change without saving")
+	newsExportCmd.Flags().StringVarP(&opmlOut, "out", "o", "", "Write OPML to a file instead of stdout")
+	newsCmd.AddCommand(newsImportCmd)
+	newsCmd.AddCommand(newsExportCmd)
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testNewsConfig() *newsConfig {
+	return &newsConfig{
+		Categories: map[string]*newsCategory{
+			"Tech": {
+				Sources: []newsSource{{URL: "https://a.example/feed", HTTP: &sourceHTTP{UserAgent: "X/1"}}},
+				Filters: newsFilters{Exclude: []newsRule{{Match: "crypto"}}},
+			},
+			"Old": {Sources: []newsSource{{URL: "https://old.example/feed"}}},
+		},
+		Filters:  newsFilters{Highlight: []newsRule{{Match: "go"}}},
+		Podcasts: podcastConfig{Dir: "/tmp/pods"},
+	}
+}
+
+func testImportedConfig() *newsConfig {
+	return &newsConfig{Categories: map[string]*newsCategory{
+		"Tech": {Sources: []newsSource{{URL: "https://a.example/feed", Title: "A"}, {URL: "https://b.example/feed"}}},
+	}}
+}
+
+func TestMergeNewsConfigKeepsSettings(t *testing.T) {
+	current := testNewsConfig()
+	merged := mergeNewsConfig(current, testImportedConfig())
+
+	if len(merged.Filters.Highlight) != 1 || merged.Podcasts.Dir != "/tmp/pods" {
+		t.Errorf("global filters or podcast settings lost: %+v", merged)
+	}
+	tech := merged.Categories["Tech"]
+	if len(tech.Filters.Exclude) != 1 {
+		t.Error("category filters lost")
+	}
+	if len(tech.Sources) != 2 || tech.Sources[0].Title != "A" || tech.Sources[0].HTTP == nil {
+		t.Errorf("unexpected Tech sources: %+v", tech.Sources)
+	}
+	if _, ok := merged.Categories["Old"]; !ok {
+		t.Error("merge removed a category")
+	}
+	if current.Categories["Tech"].Sources[0].Title != "" {
+		t.Error("merge modified the current config")
+	}
+}
+
+func TestReplaceNewsConfigKeepsSettings(t *testing.T) {
+	replaced := replaceNewsConfig(testNewsConfig(), testImportedConfig())
+
+	if len(replaced.Filters.Highlight) != 1 || replaced.Podcasts.Dir != "/tmp/pods" {
+		t.Errorf("global filters or podcast settings lost: %+v", replaced)
+	}
+	if _, ok := replaced.Categories["Old"]; ok {
+		t.Error("replace kept a category missing from the import")
+	}
+	tech := replaced.Categories["Tech"]
+	if len(tech.Filters.Exclude) != 1 || tech.Sources[0].HTTP == nil {
+		t.Errorf("category filters or source options lost: %+v", tech)
+	}
+}
+
+func TestSettingChange(t *testing.T) {
+	some := newsFilters{Include: []newsRule{{Match: "x"}}}
+	cases := []struct {
+		before, after newsFilters
+		want          string
+	}{
+		{newsFilters{}, newsFilters{}, ""},
+		{some, some, ""},
+		{newsFilters{}, some, "added"},
+		{some, newsFilters{}, "removed"},
+		{some, newsFilters{Include: []newsRule{{Match: "y"}}}, "changed"},
+	}
+	for _, c := range cases {
+		if got := settingChange(c.before, c.after); got != c.want {
+			t.Errorf("settingChange(%v, %v) = %q, want %q", c.before, c.after, got, c.want)
+		}
+	}
+}
+
+func TestOPMLRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	cfg := &newsConfig{Categories: map[string]*newsCategory{
+		"Tech":       {Sources: []newsSource{{URL: "https://a.example/feed", Title: "A"}}},
+		"Tech/Linux": {Sources: []newsSource{{URL: "https://lwn.example/rss", Title: "LWN"}, {URL: "https://untitled.example/feed"}}},
+		"World":      {Sources: []newsSource{{URL: "http://w.example/feed", Title: "W"}}},
+	}}
+
+	out, err := renderOPML(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, skipped, err := parseOPML(strings.NewReader(string(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("skipped feeds from our own export: %v", skipped)
+	}
+	if !reflect.DeepEqual(parsed.Categories, cfg.Categories) {
+		t.Errorf("round trip changed the categories:\n%s", out)
+	}
+}
+
+const testOPML = `<?xml version="1.0"?>
+<opml version="1.0">
+  <body>
+    <outline text="Loose" xmlUrl="https://loose.example/feed"/>
+    <outline text="Tech">
+      <outline title="A" text="a" url="https://a.example/feed"/>
+      <outline text="Linux">
+        <outline text="LWN" xmlUrl="https://lwn.example/rss" url="https://ignored.example/"/>
+        <outline text="Gopher" xmlUrl="gopher://old.example/feed"/>
+      </outline>
+      <outline text="No host" xmlUrl="https:///feed"/>
+    </outline>
+  </body>
+</opml>`
+
+func TestParseOPML(t *testing.T) {
+	cfg, skipped, err := parseOPML(strings.NewReader(testOPML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]*newsCategory{
+		opmlDefaultCategory: {Sources: []newsSource{{URL: "https://loose.example/feed", Title: "Loose"}}},
+		"Tech":              {Sources: []newsSource{{URL: "https://a.example/feed", Title: "A"}}},
+		"Tech/Linux":        {Sources: []newsSource{{URL: "https://lwn.example/rss", Title: "LWN"}}},
+	}
+	if !reflect.DeepEqual(cfg.Categories, want) {
+		for name, cat := range cfg.Categories {
+			t.Errorf("%s: %+v", name, cat.Sources)
+		}
+	}
+	if len(skipped) != 2 || !strings.Contains(skipped[0], "gopher://") || !strings.Contains(skipped[1], "No host") {
+		t.Errorf("unexpected skipped feeds: %q", skipped)
+	}
+
+	if _, _, err := parseOPML(strings.NewReader("<opml><body>")); err == nil {
+		t.Error("parsed a truncated file")
+	}
+}
+
+func TestImportedOPMLMergeAndReplace(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	out, err := renderOPML(testImportedConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, _, err := parseOPML(strings.NewReader(string(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged := mergeNewsConfig(testNewsConfig(), imported)
+	if len(merged.Categories["Tech"].Sources) != 2 || len(merged.Categories["Old"].Sources) != 1 {
+		t.Errorf("unexpected merge: %+v", merged.Categories)
+	}
+	replaced := replaceNewsConfig(testNewsConfig(), imported)
+	if _, ok := replaced.Categories["Old"]; ok || len(replaced.Categories["Tech"].Sources) != 2 {
+		t.Errorf("unexpected replace: %+v", replaced.Categories)
+	}
+	if replaced.Categories["Tech"].Sources[0].HTTP == nil {
+		t.Error("replace dropped the options of a source it kept")
+	}
+}
@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"brightside-go/ui"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mattn/go-runewidth"
+	"github.com/mmcdole/gofeed"
+	"golang.org/x/net/html"
+)
+
+// Output formats supported by the news command
+var newsOutputFormats = []string{"text", "json", "ndjson", "csv", "markdown"}
+
+// A feed item flattened for display and export
+type newsItem struct {
+	Category  string     `json:"category"`
+	Feed      string     `json:"feed"`
+	FeedURL   string     `json:"feed_url"`
+	Title     string     `json:"title"`
+	Link      string     `json:"link"`
+	Published *time.Time `json:"published,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	Sources   []string   `json:"sources,omitempty"`
+	Highlight string     `json:"highlight,omitempty"`
+	Summary   string     `json:"summary,omitempty"`
+
+	Score         *int   `json:"score,omitempty"`
+	Comments      *int   `json:"comments,omitempty"`
+	DiscussionURL string `json:"discussion_url,omitempty"`
+	Subreddit     string `json:"subreddit,omitempty"`
+
+	Enclosures []newsEnclosure `json:"enclosures,omitempty"`
+
+	raw  *gofeed.Item
+	also []*gofeed.Item
+}
+
+// A block of items shown under one heading (usually one feed)
+type newsSection struct {
+	Title  string
+	URL    string
+	Cached bool
+	Items  []newsItem
+}
+
+// Flatten a gofeed item
+func newsItemFrom(category, feedURL string, feed *gofeed.Feed, item *gofeed.Item) newsItem {
+	published := item.PublishedParsed
+	if published == nil {
+		published = item.UpdatedParsed
+	}
+	return newsItem{
+		Category:   category,
+		Feed:       feed.Title,
+		FeedURL:    feedURL,
+		Title:      strings.TrimSpace(item.Title),
+		Link:       item.Link,
+		Published:  published,
+		Author:     itemAuthor(item),
+		Enclosures: itemEnclosures(item),
+		raw:        item,
+	}
+}
+
+// Best-effort author name for an item
+func itemAuthor(item *gofeed.Item) string {
+	for _, p := range item.Authors {
+		if p != nil && p.Name != "" {
+			return p.Name
+		}
+	}
+	if item.Author != nil && item.Author.Name != "" {
+		return item.Author.Name
+	}
+	if item.DublinCoreExt != nil && len(item.DublinCoreExt.Creator) > 0 {
+		return item.DublinCoreExt.Creator[0]
+	}
+	return ""
+}
+
+// Check that an output format is supported
+func validNewsOutput(format string) bool {
+	for _, f := range newsOutputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Where progress and error messages go. Structured output keeps stdout clean.
+func newsStatusWriter(format string) io.Writer {
+	if format == "text" {
+		return os.Stdout
+	}
+	return os.Stderr
+}
+
+// Disable ANSI colors (e.g. when stdout is not a terminal)
+func disableColors() {
+	Blue, Cyan, Green, Gray, Reset = "", "", "", "", ""
+	for name := range highlightColors {
+		highlightColors[name] = ""
+	}
+}
+
+// How news is rendered
+type newsRenderOptions struct {
+	Format string // one of newsOutputFormats
+	Wide   bool   // author, date and age of each item
+	Width  int    // columns of text output, the terminal's if 0
+}
+
+// Render news sections in the format of the options
+func renderNews(w io.Writer, sections []newsSection, opts newsRenderOptions) error {
+	switch opts.Format {
+	case "json":
+		items := flattenSections(sections)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, item := range flattenSections(sections) {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		return renderNewsCSV(w, sections)
+	case "markdown":
+		return renderNewsMarkdown(w, sections, opts)
+	default:
+		return renderNewsText(w, sections, opts)
+	}
+}
+
+// All items of all sections, in order
+func flattenSections(sections []newsSection) []newsItem {
+	items := []newsItem{}
+	for _, s := range sections {
+		items = append(items, s.Items...)
+	}
+	return items
+}
+
+// Published time formatted for structured output
+func formatPublished(item newsItem) string {
+	if item.Published == nil {
+		return ""
+	}
+	return item.Published.UTC().Format(time.RFC3339)
+}
+
+// Colored terminal output
+func renderNewsText(w io.Writer, sections []newsSection, opts newsRenderOptions) error {
+	width := opts.Width
+	if width <= 0 {
+		width, _ = terminalSize()
+	}
+	n := 0
+	for _, s := range sections {
+		if s.Cached {
+			fmt.Fprintf(w, Blue+"📰 %s "+Gray+"(cached)\n"+Reset, s.Title)
+		} else {
+			fmt.Fprintf(w, Blue+"📰 %s\n"+Reset, s.Title)
+		}
+		for _, item := range s.Items {
+			n++
+			icon, color := "🔹", Green
+			if item.Highlight != "" {
+				icon, color = "⭐", highlightColors[item.Highlight]
+			}
+			head := fmt.Sprintf("  %s [%d] ", icon, n)
+			link := fmt.Sprintf(" (%s)", item.Link)
+			title := fitTitle(item.Title, width, runewidth.StringWidth(head+link))
+			fmt.Fprintln(w, color+head+title+Cyan+link+Reset)
+			if opts.Wide {
+				if details := itemDetails(item); len(details) > 0 {
+					fmt.Fprintln(w, Gray+fitWidth("     "+strings.Join(details, " · "), width)+Reset)
+				}
+			}
+			if len(item.Sources) > 1 {
+				fmt.Fprintf(w, Gray+"     via %s\n"+Reset, strings.Join(item.Sources, ", "))
+			}
+			if stats := itemStats(item); stats != "" {
+				fmt.Fprintf(w, Gray+"     %s\n"+Reset, stats)
+			}
+			for _, enc := range item.Enclosures {
+				fmt.Fprintf(w, Gray+"     %s %s\n"+Reset, enc.icon(), enc.describe())
+			}
+			if item.Summary != "" {
+				for _, line := range wrapText(item.Summary, width, "     ", "     ") {
+					fmt.Fprintln(w, line)
+				}
+			}
+		}
+		fmt.Fprintln(w, Gray+"-------------------------------------------------"+Reset)
+	}
+	return nil
+}
+
+// One CSV row per item, with a header row
+func renderNewsCSV(w io.Writer, sections []newsSection) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"category", "feed", "title", "link", "published", "author", "sources", "score", "comments", "discussion_url", "subreddit", "enclosure", "summary"})
+	for _, item := range flattenSections(sections) {
+		cw.Write([]string{item.Category, item.Feed, item.Title, item.Link, formatPublished(item), item.Author,
+			strings.Join(item.Sources, "; "), optionalInt(item.Score), optionalInt(item.Comments), item.DiscussionURL, item.Subreddit, firstEnclosureURL(item), item.Summary})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Format an optional number for CSV
+func optionalInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+// One-line summary of score, comments and discussion link
+func itemStats(item newsItem) string {
+	var parts []string
+	if item.Score != nil {
+		parts = append(parts, fmt.Sprintf("▲ %d", *item.Score))
+	}
+	if item.Comments != nil {
+		parts = append(parts, fmt.Sprintf("💬 %d", *item.Comments))
+	}
+	if item.Subreddit != "" {
+		parts = append(parts, "r/"+item.Subreddit)
+	}
+	if item.DiscussionURL != "" && item.DiscussionURL != item.Link {
+		parts = append(parts, item.DiscussionURL)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// Markdown with one heading per section
+func renderNewsMarkdown(w io.Writer, sections []newsSection, opts newsRenderOptions) error {
+	for _, s := range sections {
+		fmt.Fprintf(w, "## %s\n\n", markdownEscape(s.Title))
+		for _, item := range s.Items {
+			line := fmt.Sprintf("- [%s](%s)", markdownEscape(item.Title), item.Link)
+			if item.Highlight != "" {
+				line = fmt.Sprintf("- ⭐ **[%s](%s)**", markdownEscape(item.Title), item.Link)
+			}
+			var meta []string
+			if item.Author != "" {
+				meta = append(meta, markdownEscape(item.Author))
+			}
+			if item.Published != nil {
+				meta = append(meta, item.Published.Format("2006-01-02 15:04"))
+				if opts.Wide {
+					meta = append(meta, ui.RelativeAge(*item.Published))
+				}
+			}
+			if len(item.Sources) > 1 {
+				meta = append(meta, "via "+markdownEscape(strings.Join(item.Sources, ", ")))
+			}
+			if item.Score != nil {
+				meta = append(meta, fmt.Sprintf("%d points", *item.Score))
+			}
+			for _, enc := range item.Enclosures {
+				meta = append(meta, fmt.Sprintf("%s [%s](%s)", enc.icon(), markdownEscape(enc.describe()), enc.URL))
+			}
+			if item.Subreddit != "" {
+				meta = append(meta, "r/"+markdownEscape(item.Subreddit))
+			}
+			if item.DiscussionURL != "" && (item.DiscussionURL != item.Link || item.Comments != nil) {
+				comments := "discussion"
+				if item.Comments != nil {
+					comments = fmt.Sprintf("%d comments", *item.Comments)
+				}
+				meta = append(meta, fmt.Sprintf("[%s](%s)", comments, item.DiscussionURL))
+			}
+			if len(meta) > 0 {
+				line += " — " + strings.Join(meta, ", ")
+			}
+			fmt.Fprintln(w, line)
+			if item.Summary != "" {
+				fmt.Fprintf(w, "  > %s\n", markdownEscape(item.Summary))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// Escape characters that would break Markdown link text
+func markdownEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, "*", `\*`, "_", `\_`, "`", "\\`").Replace(s)
+}
+
+// Convert an HTML fragment to plain text: tags dropped, entities decoded,
+// whitespace collapsed
+func htmlToText(fragment string) string {
+	if !strings.ContainsAny(fragment, "<&") {
+		return collapseSpace(fragment)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return collapseSpace(fragment)
+	}
+	doc.Find("script, style").Remove()
+
+	// Keep block boundaries as spaces so words don't run together
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode {
+			b.WriteString(" ")
+		}
+	}
+	for _, n := range doc.Nodes {
+		walk(n)
+	}
+	return collapseSpace(b.String())
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestRenderNewsWideOption(t *testing.T) {
+	published := time.Now().Add(-3 * time.Hour)
+	sections := []newsSection{{Title: "Tech", Items: []newsItem{{Title: "Story", Link: "https://example.com", Published: &published}}}}
+
+	for _, wide := range []bool{false, true} {
+		var out bytes.Buffer
+		if err := renderNews(&out, sections, newsRenderOptions{Format: "markdown", Wide: wide}); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(out.String(), "3h ago"); got != wide {
+			t.Errorf("wide=%v: age shown=%v in %q", wide, got, out.String())
+		}
+	}
+}
+
+func TestRenderNewsTextFitsWidth(t *testing.T) {
+	sections := []newsSection{{Title: "Tech", Items: []newsItem{
+		{Title: strings.Repeat("長い見出し", 20), Link: "https://example.com/a"},
+		{Title: "Short", Link: "https://example.com/b"},
+	}}}
+	var out bytes.Buffer
+	if err := renderNews(&out, sections, newsRenderOptions{Format: "text", Width: 60}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if w := runewidth.StringWidth(lines[1]); w > 60 {
+		t.Errorf("title line is %d columns wide: %q", w, lines[1])
+	}
+	if !strings.Contains(lines[1], "…") || !strings.HasSuffix(lines[1], "(https://example.com/a)") {
+		t.Errorf("long title should be cut, keeping the link: %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "Short (https://example.com/b)") {
+		t.Errorf("short title should be left alone: %q", lines[2])
+	}
+}
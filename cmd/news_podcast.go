@@ -0,0 +1,475 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/spf13/cobra"
+)
+
+// A media file attached to an item (podcast episode, video, ...)
+type newsEnclosure struct {
+	URL      string `json:"url"`
+	Type     string `json:"type,omitempty"`
+	Length   int64  `json:"length,omitempty"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// Podcast settings in the news config
+type podcastConfig struct {
+	Dir  string        `json:"dir,omitempty"`
+	Auto []podcastRule `json:"auto_download,omitempty"`
+}
+
+// Download new episodes of a category (or one feed of it) automatically
+type podcastRule struct {
+	Category string    `json:"category"`
+	Feed     string    `json:"feed,omitempty"`
+	Match    *newsRule `json:"match,omitempty"`
+	Latest   int       `json:"latest,omitempty"`
+	Convert  string    `json:"convert,omitempty"`
+}
+
+// Where episodes go unless the config says otherwise
+var defaultPodcastDir = filepath.Join(os.Getenv("HOME"), "Downloads", "Podcasts")
+
+// Enclosures of an item, with the duration from the iTunes extension
+func itemEnclosures(item *gofeed.Item) []newsEnclosure {
+	var duration string
+	if item.ITunesExt != nil {
+		duration = formatEpisodeDuration(item.ITunesExt.Duration)
+	}
+	var out []newsEnclosure
+	for _, enc := range item.Enclosures {
+		if enc == nil || enc.URL == "" {
+			continue
+		}
+		length, _ := strconv.ParseInt(enc.Length, 10, 64)
+		out = append(out, newsEnclosure{URL: enc.URL, Type: enc.Type, Length: length, Duration: duration})
+	}
+	return out
+}
+
+// iTunes durations come as seconds, mm:ss or hh:mm:ss; show them as [h:]mm:ss
+func formatEpisodeDuration(d string) string {
+	d = strings.TrimSpace(d)
+	secs, err := strconv.Atoi(d)
+	if err != nil {
+		return d
+	}
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+// Human readable byte count
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Icon for the kind of media
+func (e newsEnclosure) icon() string {
+	switch {
+	case strings.HasPrefix(e.Type, "audio/"):
+		return "🎧"
+	case strings.HasPrefix(e.Type, "video/"):
+		return "🎬"
+	default:
+		return "📎"
+	}
+}
+
+// Type, size and duration on one line
+func (e newsEnclosure) describe() string {
+	parts := []string{}
+	if e.Type != "" {
+		parts = append(parts, e.Type)
+	}
+	if e.Length > 0 {
+		parts = append(parts, formatBytes(e.Length))
+	}
+	if e.Duration != "" {
+		parts = append(parts, e.Duration)
+	}
+	if len(parts) == 0 {
+		parts = append(parts, e.URL)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// URL of the first enclosure, if any
+func firstEnclosureURL(item newsItem) string {
+	if len(item.Enclosures) == 0 {
+		return ""
+	}
+	return item.Enclosures[0].URL
+}
+
+// Directory episodes are downloaded to
+func (c *newsConfig) podcastDir() string {
+	if c.Podcasts.Dir == "" {
+		return defaultPodcastDir
+	}
+	if rest, ok := strings.CutPrefix(c.Podcasts.Dir, "~/"); ok {
+		return filepath.Join(os.Getenv("HOME"), rest)
+	}
+	return c.Podcasts.Dir
+}
+
+// Check the auto-download rules
+func (c *newsConfig) podcastRules() ([]podcastRule, error) {
+	rules := c.Podcasts.Auto
+	for i, r := range rules {
+		if _, ok := c.Categories[r.Category]; !ok {
+			return nil, fmt.Errorf("auto-download rule %d: unknown category %q", i+1, r.Category)
+		}
+		if r.Convert != "" && r.Convert != "mp3" && r.Convert != "wav" {
+			return nil, fmt.Errorf("auto-download rule %d: can only convert to mp3 or wav", i+1)
+		}
+		if r.Match != nil {
+			if err := r.Match.compile(); err != nil {
+				return nil, fmt.Errorf("auto-download rule %d: %w", i+1, err)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// Check whether a rule wants an item
+func (r podcastRule) wants(item newsItem) bool {
+	if len(item.Enclosures) == 0 || r.Category != item.Category {
+		return false
+	}
+	if r.Feed != "" && r.Feed != item.FeedURL {
+		return false
+	}
+	return r.Match == nil || r.Match.matches(item)
+}
+
+// Replace characters that are awkward in file names
+func safeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '-'
+		}
+		if r < ' ' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, ". ")
+	if len([]rune(name)) > 120 {
+		name = string([]rune(name)[:120])
+	}
+	return name
+}
+
+// Local path for an episode: <dir>/<feed>/<title>.<ext>
+func episodePath(dir string, ep listedItem) string {
+	u, err := url.Parse(ep.Enclosure)
+	base, ext := "episode", ""
+	if err == nil {
+		// path.Base gives "/" for a URL without a file name
+		base = strings.Trim(path.Base(u.Path), "/")
+		ext = path.Ext(base)
+	}
+	name := safeFileName(ep.Title)
+	if name == "" {
+		name = safeFileName(strings.TrimSuffix(base, ext))
+	}
+	if name == "" {
+		name = "episode"
+	}
+	if feed := safeFileName(ep.Feed); feed != "" {
+		dir = filepath.Join(dir, feed)
+	}
+	return filepath.Join(dir, name+ext)
+}
+
+// Downloaded episodes by enclosure URL, so auto-download never fetches one twice
+type downloadLog struct {
+	path  string
+	Items map[string]downloadRecord `json:"items"`
+}
+
+type downloadRecord struct {
+	Path string    `json:"path"`
+	At   time.Time `json:"at"`
+}
+
+// Load the download log (an empty one if it doesn't exist yet)
+func loadDownloadLog() *downloadLog {
+	log := &downloadLog{path: filepath.Join(brightsideDataDir(), "news_downloads.json")}
+	if err := readJSONFile(log.path, log); err != nil {
+		fmt.Println("⚠️ Could not read download log:", err)
+	}
+	if log.Items == nil {
+		log.Items = map[string]downloadRecord{}
+	}
+	return log
+}
+
+func (l *downloadLog) save() error {
+	return writeJSONFile(l.path, l)
+}
+
+// Download one episode, then convert it if asked to
+func downloadEpisode(dir string, ep listedItem, convert string) (string, error) {
+	if ep.Enclosure == "" {
+		return "", errors.New("item has no enclosure")
+	}
+	dest := episodePath(dir, ep)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+
+	if fileExists(dest) {
+		fmt.Println("✅ Already downloaded:", dest)
+	} else {
+		fmt.Printf("⬇️  %s\n", ep.Title)
+		if err := downloadToFile(ep.Enclosure, dest); err != nil {
+			return "", err
+		}
+		fmt.Println("✅ Saved", dest)
+	}
+
+	if convert != "" && !strings.EqualFold(filepath.Ext(dest), "."+convert) {
+		convertFile(dest, convert)
+	}
+	return dest, nil
+}
+
+// Fetch the feeds of the auto-download rules and download the newest episodes
+func autoDownloadEpisodes(cfg *newsConfig, dir string) {
+	rules, err := cfg.podcastRules()
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	if len(rules) == 0 {
+		fmt.Println("⚠️ No auto-download rules. Add them under \"podcasts\" → \"auto_download\" in", newsConfigFile)
+		return
+	}
+
+	log := loadDownloadLog()
+	fetcher := newNewsFetcher()
+	downloaded := 0
+	for _, rule := range rules {
+		feeds := cfg.sourceMap()[rule.Category]
+		if rule.Feed != "" {
+			feeds = []string{rule.Feed}
+		}
+		latest := max(rule.Latest, 1)
+
+		for _, res := range fetcher.fetchAll(feeds) {
+			if res.Err != nil {
+				fmt.Printf("⚠️ %s: %v\n", res.URL, res.Err)
+				continue
+			}
+			var items []newsItem
+			for _, item := range res.Feed.Items {
+				if ni := newsItemFrom(rule.Category, res.URL, res.Feed, item); rule.wants(ni) {
+					items = append(items, ni)
+				}
+			}
+			sortNewsItems(items)
+			for _, item := range items[:min(latest, len(items))] {
+				ep := listedItem{Title: item.Title, Link: item.Link, Feed: item.Feed, Enclosure: firstEnclosureURL(item)}
+				if _, done := log.Items[ep.Enclosure]; done {
+					continue
+				}
+				dest, err := downloadEpisode(dir, ep, rule.Convert)
+				if err != nil {
+					fmt.Println("❌ Failed to download episode:", err)
+					continue
+				}
+				log.Items[ep.Enclosure] = downloadRecord{Path: dest, At: time.Now()}
+				downloaded++
+			}
+		}
+	}
+
+	if err := log.save(); err != nil {
+		fmt.Println("❌ Failed to save download log:", err)
+	}
+	if downloaded == 0 {
+		fmt.Println(Green + "✅ No new episodes" + Reset)
+	}
+}
+
+// Downloads the episodes of new items that match an auto-download rule (used by watch)
+type podcastNotifier struct {
+	dir   string
+	rules []podcastRule
+	log   *downloadLog
+}
+
+func (n podcastNotifier) notify(item newsItem) error {
+	for _, rule := range n.rules {
+		if !rule.wants(item) {
+			continue
+		}
+		ep := listedItem{Title: item.Title, Link: item.Link, Feed: item.Feed, Enclosure: firstEnclosureURL(item)}
+		if _, done := n.log.Items[ep.Enclosure]; done {
+			return nil
+		}
+		dest, err := downloadEpisode(n.dir, ep, rule.Convert)
+		if err != nil {
+			return err
+		}
+		n.log.Items[ep.Enclosure] = downloadRecord{Path: dest, At: time.Now()}
+		return n.log.save()
+	}
+	return nil
+}
+
+// List the items with enclosures in a category or a single feed
+func listEpisodes(target string) {
+	cfg := loadNewsConfigOrDefaults()
+	category, feeds := target, cfg.sourceMap()[target]
+	if feeds == nil {
+		if err := checkFeedURL(target); err != nil {
+			fmt.Println("❌ Not a category or feed URL. Available categories:")
+			printCategories(cfg.sourceMap())
+			return
+		}
+		category, feeds = "", []string{target}
+		if name, _ := cfg.find(target); name != "" {
+			category = name
+		}
+	}
+
+	var sections []newsSection
+	var failed []feedResult
+	results := newNewsFetcher().fetchAll(feeds)
+	for _, res := range results {
+		if res.Err != nil {
+			failed = append(failed, res)
+			continue
+		}
+		var items []newsItem
+		for _, item := range res.Feed.Items {
+			if ni := newsItemFrom(category, res.URL, res.Feed, item); len(ni.Enclosures) > 0 {
+				items = append(items, ni)
+			}
+		}
+		if len(items) > episodesLimit {
+			items = items[:episodesLimit]
+		}
+		if len(items) > 0 {
+			sections = append(sections, newsSection{Title: res.Feed.Title, URL: res.URL, Cached: res.Cached, Items: items})
+		}
+	}
+
+	if len(sections) == 0 {
+		fmt.Println("⚠️ No episodes found.")
+	} else {
+		renderNewsText(os.Stdout, sections, newsRenderOptions{Format: "text"})
+		if err := saveLastListing(sections); err != nil {
+			fmt.Println("⚠️ Could not save listing for 'news download':", err)
+		}
+	}
+	printFeedErrors(os.Stdout, failed, len(results))
+}
+
+// Download the enclosure of a listed item (or a URL)
+func downloadNewsEnclosure(arg, dir string) {
+	var ep listedItem
+	if n, err := strconv.Atoi(arg); err == nil {
+		if ep, err = listedItemAt(n); err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		if ep.Enclosure == "" {
+			fmt.Printf("❌ Item %d has no enclosure. Use 'news episodes' to list episodes.\n", n)
+			return
+		}
+	} else {
+		if err := checkFeedURL(arg); err != nil {
+			fmt.Println("❌ Not an item number or URL:", err)
+			return
+		}
+		u, _ := url.Parse(arg)
+		ep = listedItem{Title: strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path)), Enclosure: arg}
+	}
+
+	dest, err := downloadEpisode(dir, ep, downloadConvert)
+	if err != nil {
+		fmt.Println("❌ Failed to download episode:", err)
+		return
+	}
+	log := loadDownloadLog()
+	log.Items[ep.Enclosure] = downloadRecord{Path: dest, At: time.Now()}
+	if err := log.save(); err != nil {
+		fmt.Println("⚠️ Could not save download log:", err)
+	}
+}
+
+// Episode and download flags
+var episodesLimit int
+var downloadDir string
+var downloadConvert string
+var downloadAuto bool
+
+var newsEpisodesCmd = &cobra.Command{
+	Use:   "episodes <category|feed URL>",
+	Short: "List podcast episodes and other enclosures",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		listEpisodes(args[0])
+	},
+}
+
+var newsDownloadCmd = &cobra.Command{
+	Use:   "download [n|URL]",
+	Short: "Download the enclosure of a listed item, or new episodes with --auto",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if downloadConvert != "" && downloadConvert != "mp3" && downloadConvert != "wav" {
+			fmt.Println("❌ Unsupported format! Use mp3 or wav.")
+			return
+		}
+		cfg := loadNewsConfigOrDefaults()
+		dir := cfg.podcastDir()
+		if downloadDir != "" {
+			dir = downloadDir
+		}
+		if downloadAuto {
+			autoDownloadEpisodes(cfg, dir)
+			return
+		}
+		if len(args) == 0 {
+			fmt.Println("❌ Give an item number from the last listing, a URL or --auto")
+			return
+		}
+		downloadNewsEnclosure(args[0], dir)
+	},
+}
+
+func init() {
+	newsEpisodesCmd.Flags().IntVarP(&episodesLimit, "limit", "l", 10, "Number of episodes per feed")
+	newsDownloadCmd.Flags().StringVarP(&downloadDir, "dir", "d", "", "Download directory (default: podcasts.dir from the config or ~/Downloads/Podcasts)")
+	newsDownloadCmd.Flags().StringVarP(&downloadConvert, "convert", "c", "", "Convert audio after downloading (mp3, wav)")
+	newsDownloadCmd.Flags().BoolVar(&downloadAuto, "auto", false, "Download new episodes matching the auto-download rules in the config")
+	newsCmd.AddCommand(newsEpisodesCmd)
+	newsCmd.AddCommand(newsDownloadCmd)
+}
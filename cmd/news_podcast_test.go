@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/gofeed"
+)
+
+const testPodcastFeed = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<channel><title>Pod</title>
+<item>
+  <title>Episode 1</title>
+  <itunes:duration>3725</itunes:duration>
+  <enclosure url="https://cdn.example/ep1.mp3?token=x" type="audio/mpeg" length="1572864"/>
+</item>
+<item>
+  <title>Episode 2</title>
+  <enclosure url="" type="audio/mpeg" length="1"/>
+</item>
+</channel></rss>`
+
+func TestItemEnclosures(t *testing.T) {
+	feed, err := gofeed.NewParser().ParseString(testPodcastFeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encs := itemEnclosures(feed.Items[0])
+	if len(encs) != 1 {
+		t.Fatalf("got %d enclosures, want 1", len(encs))
+	}
+	want := newsEnclosure{URL: "https://cdn.example/ep1.mp3?token=x", Type: "audio/mpeg", Length: 1572864, Duration: "1:02:05"}
+	if encs[0] != want {
+		t.Errorf("got %+v, want %+v", encs[0], want)
+	}
+	if got := encs[0].describe(); got != "audio/mpeg · 1.5 MB · 1:02:05" {
+		t.Errorf("describe() = %q", got)
+	}
+
+	// Enclosures without a URL are skipped
+	if encs := itemEnclosures(feed.Items[1]); len(encs) != 0 {
+		t.Errorf("got %+v for an empty enclosure", encs)
+	}
+}
+
+func TestFormatEpisodeDuration(t *testing.T) {
+	cases := map[string]string{
+		"59":       "0:59",
+		"754":      "12:34",
+		"3600":     "1:00:00",
+		" 42:10 ":  "42:10",
+		"01:02:03": "01:02:03",
+	}
+	for in, want := range cases {
+		if got := formatEpisodeDuration(in); got != want {
+			t.Errorf("formatEpisodeDuration(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestEpisodePath(t *testing.T) {
+	cases := []struct {
+		ep   listedItem
+		want string
+	}{
+		{listedItem{Title: "Ep 1: Intro?", Feed: "My/Pod", Enclosure: "https://cdn.example/a/ep1.mp3?x=1"}, filepath.Join("pods", "My-Pod", "Ep 1- Intro-.mp3")},
+		{listedItem{Title: "  ", Enclosure: "https://cdn.example/a/ep1.m4a"}, filepath.Join("pods", "ep1.m4a")},
+		{listedItem{Title: "...", Feed: "Pod", Enclosure: "https://cdn.example/"}, filepath.Join("pods", "Pod", "episode")},
+	}
+	for _, c := range cases {
+		if got := episodePath("pods", c.ep); got != c.want {
+			t.Errorf("episodePath(%+v) = %q, want %q", c.ep, got, c.want)
+		}
+	}
+}
+
+func TestPodcastRuleWants(t *testing.T) {
+	episode := newsItem{Category: "Pods", FeedURL: "https://a.example/feed", Title: "Weekly news", Enclosures: []newsEnclosure{{URL: "https://a.example/1.mp3"}}}
+	article := newsItem{Category: "Pods", FeedURL: "https://a.example/feed", Title: "Weekly news"}
+	match := &newsRule{Match: "weekly"}
+	if err := match.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		rule podcastRule
+		item newsItem
+		want bool
+	}{
+		{podcastRule{Category: "Pods"}, episode, true},
+		{podcastRule{Category: "Pods"}, article, false},
+		{podcastRule{Category: "Tech"}, episode, false},
+		{podcastRule{Category: "Pods", Feed: "https://b.example/feed"}, episode, false},
+		{podcastRule{Category: "Pods", Match: match}, episode, true},
+		{podcastRule{Category: "Pods", Match: match}, newsItem{Category: "Pods", Title: "Daily", Enclosures: episode.Enclosures}, false},
+	}
+	for i, c := range cases {
+		if got := c.rule.wants(c.item); got != c.want {
+			t.Errorf("case %d: wants = %v, want %v", i+1, got, c.want)
+		}
+	}
+}
+
+func TestPodcastRulesValidation(t *testing.T) {
+	cfg := &newsConfig{Categories: map[string]*newsCategory{"Pods": {}}}
+	cases := []struct {
+		rule podcastRule
+		err  string
+	}{
+		{podcastRule{Category: "Pods", Convert: "mp3"}, ""},
+		{podcastRule{Category: "Other"}, "unknown category"},
+		{podcastRule{Category: "Pods", Convert: "flac"}, "mp3 or wav"},
+	}
+	for _, c := range cases {
+		cfg.Podcasts.Auto = []podcastRule{c.rule}
+		_, err := cfg.podcastRules()
+		if (c.err == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%+v: got %v, want %q", c.rule, err, c.err)
+		}
+	}
+}
+
+func TestDownloadEpisode(t *testing.T) {
+	content := bytes.Repeat([]byte("episode "), 4096)
+	srv, full := newTestFileServer(t, content, `"ep"`)
+	dir := t.TempDir()
+	ep := listedItem{Title: "Episode 1", Feed: "Pod", Enclosure: srv.URL + "/ep1.mp3"}
+
+	dest, err := downloadEpisode(dir, ep, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dest != filepath.Join(dir, "Pod", "Episode 1.mp3") {
+		t.Errorf("saved to %s", dest)
+	}
+	if got, err := os.ReadFile(dest); err != nil || !bytes.Equal(got, content) {
+		t.Errorf("downloaded file differs: %v", err)
+	}
+	if fileExists(dest + ".part") {
+		t.Error("partial file left behind")
+	}
+
+	// A finished episode isn't fetched again
+	if _, err := downloadEpisode(dir, ep, ""); err != nil || full.Load() != 1 {
+		t.Errorf("second download: %v, %d full requests", err, full.Load())
+	}
+
+	if _, err := downloadEpisode(dir, listedItem{Title: "No media"}, ""); err == nil {
+		t.Error("item without an enclosure gave no error")
+	}
+}
@@ -0,0 +1,400 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/html"
+)
+
+// Widest the reader will wrap text, even on very wide terminals
+const readerMaxWidth = 100
+
+// Class/id hints for content and for page furniture (readability-style)
+var (
+	positiveHints = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|story|text|blog`)
+	negativeHints = regexp.MustCompile(`(?i)comment|sidebar|footer|foot|masthead|nav|menu|share|social|promo|related|sponsor|advert|\bad-|banner|subscribe|newsletter|cookie|popup|modal|widget|breadcrumb`)
+)
+
+// Elements that never hold article text
+const junkSelector = "script, style, noscript, iframe, form, nav, header, footer, aside, svg, button, select, input, [role=navigation], [role=complementary], [aria-hidden=true]"
+
+// One block of article text
+type articleBlock struct {
+	Kind string // heading, paragraph, item, quote, code
+	Text string
+}
+
+// The readable part of a web page
+type article struct {
+	Title  string
+	URL    string
+	Blocks []articleBlock
+}
+
+// An entry of the last numbered listing, so `news read 3` can find it
+type listedItem struct {
+	Title     string `json:"title"`
+	Link      string `json:"link"`
+	Feed      string `json:"feed,omitempty"`
+	Enclosure string `json:"enclosure,omitempty"`
+}
+
+// File holding the last numbered listing
+func lastListingPath() string {
+	return filepath.Join(brightsideDataDir(), "news_last.json")
+}
+
+// Remember the items of the last listing, in display order
+func saveLastListing(sections []newsSection) error {
+	listed := []listedItem{}
+	for _, item := range flattenSections(sections) {
+		li := listedItem{Title: item.Title, Link: item.Link, Feed: item.Feed}
+		if len(item.Enclosures) > 0 {
+			li.Enclosure = item.Enclosures[0].URL
+		}
+		listed = append(listed, li)
+	}
+	return writeJSONFile(lastListingPath(), listed)
+}
+
+// Look up item n (1-based) of the last listing
+func listedItemAt(n int) (listedItem, error) {
+	var listed []listedItem
+	if err := readJSONFile(lastListingPath(), &listed); err != nil {
+		return listedItem{}, err
+	}
+	if len(listed) == 0 {
+		return listedItem{}, errors.New("no previous listing, run 'news [category]' first")
+	}
+	if n < 1 || n > len(listed) {
+		return listedItem{}, fmt.Errorf("item number must be between 1 and %d", len(listed))
+	}
+	return listed[n-1], nil
+}
+
+// Resolve `news read` input: a number from the last listing or a URL
+func resolveArticle(arg string) (string, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		if err := checkFeedURL(arg); err != nil {
+			return "", fmt.Errorf("not an item number or URL: %w", err)
+		}
+		return arg, nil
+	}
+	item, err := listedItemAt(n)
+	return item.Link, err
+}
+
+// Download a page and extract its main content
+func fetchArticle(pageURL string) (*article, error) {
+	ctx := context.Background()
+	if newsTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, newsTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", newsUserAgent)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("http error: %s", resp.Status)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	art := extractArticle(doc)
+	art.URL = resp.Request.URL.String()
+	if len(art.Blocks) == 0 {
+		return nil, errors.New("could not find any article text on the page")
+	}
+	return art, nil
+}
+
+// Pick the element most likely to hold the article and turn it into blocks
+func extractArticle(doc *goquery.Document) *article {
+	art := &article{Title: strings.TrimSpace(doc.Find(`meta[property="og:title"]`).AttrOr("content", ""))}
+	if art.Title == "" {
+		art.Title = collapseSpace(doc.Find("title").First().Text())
+	}
+
+	doc.Find(junkSelector).Remove()
+	doc.Find("*").Each(func(_ int, s *goquery.Selection) {
+		hints := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if negativeHints.MatchString(hints) && !positiveHints.MatchString(hints) && s.Is("div, section, ul, span, p") {
+			s.Remove()
+		}
+	})
+
+	// Score the parents of every paragraph, like readability does
+	scores := map[*html.Node]float64{}
+	var candidates []*goquery.Selection
+	doc.Find("p, pre, td").Each(func(_ int, p *goquery.Selection) {
+		text := collapseSpace(p.Text())
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+
+		for depth, parent := 0, p.Parent(); depth < 2 && parent.Length() > 0; depth, parent = depth+1, parent.Parent() {
+			node := parent.Get(0)
+			if _, ok := scores[node]; !ok {
+				scores[node] = initialScore(parent)
+				candidates = append(candidates, parent)
+			}
+			scores[node] += score / float64(depth+1)
+		}
+	})
+
+	var best *goquery.Selection
+	bestScore := 0.0
+	for _, c := range candidates {
+		score := scores[c.Get(0)] * (1 - linkDensity(c))
+		if best == nil || score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	if best == nil {
+		best = doc.Find("body")
+	}
+
+	art.Blocks = collectBlocks(best)
+	if len(art.Blocks) > 0 && art.Blocks[0].Kind == "heading" && art.Blocks[0].Text == art.Title {
+		art.Blocks = art.Blocks[1:]
+	}
+	return art
+}
+
+// Starting score of a candidate from its tag and class/id hints
+func initialScore(s *goquery.Selection) float64 {
+	score := 0.0
+	switch goquery.NodeName(s) {
+	case "article", "main":
+		score += 10
+	case "div", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "form", "ul", "ol", "li", "dl":
+		score -= 3
+	}
+	hints := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+	if positiveHints.MatchString(hints) {
+		score += 25
+	}
+	if negativeHints.MatchString(hints) {
+		score -= 25
+	}
+	return score
+}
+
+// Share of an element's text that sits inside links
+func linkDensity(s *goquery.Selection) float64 {
+	total := len(collapseSpace(s.Text()))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += len(collapseSpace(a.Text()))
+	})
+	return float64(links) / float64(total)
+}
+
+// Walk the content element and collect headings, paragraphs, list items, quotes and code
+func collectBlocks(root *goquery.Selection) []articleBlock {
+	var blocks []articleBlock
+	add := func(kind, text string) {
+		if text != "" {
+			blocks = append(blocks, articleBlock{Kind: kind, Text: text})
+		}
+	}
+
+	var walk func(s *goquery.Selection)
+	walk = func(s *goquery.Selection) {
+		s.Contents().Each(func(_ int, c *goquery.Selection) {
+			node := c.Get(0)
+			if node.Type == html.TextNode {
+				add("paragraph", collapseSpace(node.Data))
+				return
+			}
+			if node.Type != html.ElementNode {
+				return
+			}
+			switch goquery.NodeName(c) {
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				add("heading", collapseSpace(c.Text()))
+			case "p":
+				add("paragraph", collapseSpace(c.Text()))
+			case "li":
+				add("item", collapseSpace(c.Text()))
+			case "blockquote":
+				add("quote", collapseSpace(c.Text()))
+			case "pre":
+				add("code", strings.Trim(c.Text(), "\n"))
+			case "img", "figure", "picture", "video", "audio", "table":
+				// Not renderable as text
+			case "a", "span", "em", "strong", "b", "i", "code", "small", "sup", "sub", "time", "abbr":
+				add("paragraph", collapseSpace(c.Text()))
+			default:
+				walk(c)
+			}
+		})
+	}
+	walk(root)
+	return blocks
+}
+
+// Collapse runs of whitespace into single spaces
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Render an article for the terminal, word-wrapped to width
+func renderArticleText(art *article, width int) string {
+	var b strings.Builder
+	for _, line := range wrapText(art.Title, width, "", "") {
+		b.WriteString(Blue + line + Reset + "\n")
+	}
+	b.WriteString(Gray + art.URL + Reset + "\n\n")
+
+	for _, block := range art.Blocks {
+		switch block.Kind {
+		case "heading":
+			for _, line := range wrapText(block.Text, width, "", "") {
+				b.WriteString(Green + line + Reset + "\n")
+			}
+		case "item":
+			b.WriteString(strings.Join(wrapText(block.Text, width, "  • ", "    "), "\n") + "\n")
+		case "quote":
+			b.WriteString(Cyan + strings.Join(wrapText(block.Text, width, "  │ ", "  │ "), "\n") + Reset + "\n")
+		case "code":
+			for _, line := range strings.Split(block.Text, "\n") {
+				b.WriteString("    " + line + "\n")
+			}
+		default:
+			b.WriteString(strings.Join(wrapText(block.Text, width, "", ""), "\n") + "\n")
+		}
+		if block.Kind != "item" {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Render an article as Markdown
+func renderArticleMarkdown(art *article) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n<%s>\n\n", art.Title, art.URL)
+	for i, block := range art.Blocks {
+		switch block.Kind {
+		case "heading":
+			fmt.Fprintf(&b, "## %s\n", block.Text)
+		case "item":
+			fmt.Fprintf(&b, "- %s\n", block.Text)
+		case "quote":
+			fmt.Fprintf(&b, "> %s\n", block.Text)
+		case "code":
+			fmt.Fprintf(&b, "```\n%s\n```\n", block.Text)
+		default:
+			fmt.Fprintf(&b, "%s\n", block.Text)
+		}
+		// Keep list items together, separate everything else
+		if block.Kind != "item" || i+1 == len(art.Blocks) || art.Blocks[i+1].Kind != "item" {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Show text through $PAGER (or less) when it doesn't fit on the screen
+func page(text string, usePager bool) {
+	_, height := terminalSize()
+	if !usePager || !stdoutIsTerminal() || strings.Count(text, "\n") < height {
+		fmt.Print(text)
+		return
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less -R"
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Print(text)
+	}
+}
+
+// Read an article in the terminal
+func readArticle(arg string) {
+	pageURL, err := resolveArticle(arg)
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "📖 Loading %s ...\n", pageURL)
+	art, err := fetchArticle(pageURL)
+	if err != nil {
+		fmt.Println("❌ Failed to read article:", err)
+		return
+	}
+
+	if readSavePath != "" {
+		if err := os.WriteFile(readSavePath, []byte(renderArticleMarkdown(art)), 0644); err != nil {
+			fmt.Println("❌ Failed to save article:", err)
+			return
+		}
+		fmt.Println(Green+"✅ Saved article to"+Reset, readSavePath)
+		return
+	}
+
+	width, _ := terminalSize()
+	if width > readerMaxWidth {
+		width = readerMaxWidth
+	}
+	page(renderArticleText(art, width), !readNoPager)
+}
+
+// Read command flags
+var readSavePath string
+var readNoPager bool
+
+var newsReadCmd = &cobra.Command{
+	Use:   "read [number|url]",
+	Short: "Read an article from the last news listing in the terminal",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		readArticle(args[0])
+	},
+}
+
+func init() {
+	newsReadCmd.Flags().StringVarP(&readSavePath, "save", "s", "", "Save the article as Markdown instead of showing it")
+	newsReadCmd.Flags().BoolVar(&readNoPager, "no-pager", false, "Print the article without a pager")
+	newsCmd.AddCommand(newsReadCmd)
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const testArticlePage = `<!doctype html>
+<html><head>
+  <title>Site | Ignored title</title>
+  <meta property="og:title" content="Bright news, finally">
+  <script>var tracking = "should never show up in the article text";</script>
+</head><body>
+  <nav><a href="/">Home</a> <a href="/world">World, politics, business and more sections</a></nav>
+  <div class="sidebar">Trending now: a story that is not part of this article, at all.</div>
+  <article class="post-content">
+    <h1>Bright news, finally</h1>
+    <p>The first paragraph is long enough to count, with commas, clauses, and detail.</p>
+    <h2>What happened</h2>
+    <p>The second paragraph continues the story, adding more, and more, context here.</p>
+    <ul><li>One point</li><li>Another point</li></ul>
+    <blockquote>A quote from someone involved in the story.</blockquote>
+    <pre>go run .</pre>
+  </article>
+  <div id="comments"><p>First comment, which is long enough to look like a paragraph.</p></div>
+  <footer><p>Copyright notice that is long enough to be scored as a paragraph.</p></footer>
+</body></html>`
+
+func TestExtractArticle(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(testArticlePage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	art := extractArticle(doc)
+
+	if art.Title != "Bright news, finally" {
+		t.Errorf("title = %q", art.Title)
+	}
+	var kinds []string
+	for _, b := range art.Blocks {
+		kinds = append(kinds, b.Kind)
+		for _, junk := range []string{"tracking", "Trending", "comment", "Copyright", "Home"} {
+			if strings.Contains(b.Text, junk) {
+				t.Errorf("page furniture in the article: %q", b.Text)
+			}
+		}
+	}
+	// The heading repeating the title is dropped
+	want := []string{"paragraph", "heading", "paragraph", "item", "item", "quote", "code"}
+	if !slices.Equal(kinds, want) {
+		t.Errorf("blocks = %v, want %v", kinds, want)
+	}
+}
+
+func TestRenderArticleMarkdown(t *testing.T) {
+	art := &article{Title: "T", URL: "https://a.example/1", Blocks: []articleBlock{
+		{Kind: "paragraph", Text: "Intro"},
+		{Kind: "item", Text: "One"},
+		{Kind: "item", Text: "Two"},
+		{Kind: "code", Text: "go run ."},
+	}}
+	want := "# T\n\n<https://a.example/1>\n\nIntro\n\n- One\n- Two\n\n```\ngo run .\n```\n\n"
+	if got := renderArticleMarkdown(art); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFetchArticle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/story":
+			w.Write([]byte(testArticlePage))
+		case "/empty":
+			w.Write([]byte("<html><body></body></html>"))
+		case "/moved":
+			http.Redirect(w, r, "/story", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	art, err := fetchArticle(srv.URL + "/moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if art.URL != srv.URL+"/story" {
+		t.Errorf("URL = %q, want the one after redirects", art.URL)
+	}
+	if _, err := fetchArticle(srv.URL + "/empty"); err == nil {
+		t.Error("page without text gave no error")
+	}
+	if _, err := fetchArticle(srv.URL + "/missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("missing page: %v", err)
+	}
+}
+
+func TestResolveArticle(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if _, err := resolveArticle("1"); err == nil {
+		t.Error("resolved a number without a listing")
+	}
+
+	sections := []newsSection{{Title: "Feed", Items: []newsItem{
+		{Title: "One", Link: "https://a.example/1"},
+		{Title: "Two", Link: "https://a.example/2"},
+	}}}
+	if err := saveLastListing(sections); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		arg, want string
+		ok        bool
+	}{
+		{"2", "https://a.example/2", true},
+		{"3", "", false},
+		{"0", "", false},
+		{"https://b.example/x", "https://b.example/x", true},
+		{"b.example/x", "", false},
+	}
+	for _, c := range cases {
+		got, err := resolveArticle(c.arg)
+		if (err == nil) != c.ok || got != c.want {
+			t.Errorf("resolveArticle(%q) = %q, %v", c.arg, got, err)
+		}
+	}
+}
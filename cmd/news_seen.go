@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/spf13/cobra"
+)
+
+// How long read markers are kept before being forgotten
+const seenRetention = 180 * 24 * time.Hour
+
+// Persistent set of items that have already been read
+type seenStore struct {
+	path  string
+	Items map[string]time.Time `json:"items"`
+}
+
+// Identify an item by GUID, falling back to its link (and title as a last resort)
+func itemKey(item *gofeed.Item) string {
+	if item.GUID != "" {
+		return item.GUID
+	}
+	if item.Link != "" {
+		return item.Link
+	}
+	return item.Title
+}
+
+// Load the read markers from the data directory
+func loadSeenStore() *seenStore {
+	s := &seenStore{
+		path:  filepath.Join(brightsideDataDir(), "news_seen.json"),
+		Items: map[string]time.Time{},
+	}
+	if err := readJSONFile(s.path, s); err != nil {
+		fmt.Fprintln(os.Stderr, "⚠️ Could not read seen items, starting fresh:", err)
+	}
+	if s.Items == nil {
+		s.Items = map[string]time.Time{}
+	}
+	return s
+}
+
+// Check whether an item was already read
+func (s *seenStore) has(item *gofeed.Item) bool {
+	_, ok := s.Items[itemKey(item)]
+	return ok
+}
+
+// Mark an item as read, returning false if it already was
+func (s *seenStore) mark(item *gofeed.Item) bool {
+	key := itemKey(item)
+	if _, ok := s.Items[key]; ok {
+		return false
+	}
+	s.Items[key] = time.Now()
+	return true
+}
+
+// Save the read markers, dropping ones past the retention window
+func (s *seenStore) save() error {
+	cutoff := time.Now().Add(-seenRetention)
+	for key, at := range s.Items {
+		if at.Before(cutoff) {
+			delete(s.Items, key)
+		}
+	}
+	return writeJSONFile(s.path, s)
+}
+
+// Print the available categories with feed and unread counts (from the cache only)
+func printCategories(sources map[string][]string) {
+	names := sortedCategories(sources)
+
+	seen := loadSeenStore()
+	cache := &feedFetcher{cache: newFeedCache(), offline: true, workers: newsWorkers}
+
+	for _, name := range names {
+		unread, known := 0, false
+		for _, res := range cache.fetchAll(sources[name]) {
+			if res.Err != nil {
+				continue
+			}
+			known = true
+			for _, item := range res.Feed.Items {
+				if !seen.has(item) {
+					unread++
+				}
+			}
+		}
+
+		if known {
+			fmt.Printf(Green+" - %s "+Gray+"(%d feeds, %d unread)\n"+Reset, name, len(sources[name]), unread)
+		} else {
+			fmt.Printf(Green+" - %s "+Gray+"(%d feeds)\n"+Reset, name, len(sources[name]))
+		}
+	}
+}
+
+// Mark every item in a category (or all categories) as read
+func markNewsRead(category string) {
+	sources := loadNewsSources()
+
+	categories := []string{category}
+	if category == "" {
+		categories = sortedCategories(sources)
+	} else if _, exists := sources[category]; !exists {
+		fmt.Println("❌ Invalid category. Available categories:")
+		printCategories(sources)
+		return
+	}
+
+	seen := loadSeenStore()
+	fetcher := newNewsFetcher()
+
+	for _, name := range categories {
+		results := fetcher.fetchAll(sources[name])
+		marked := 0
+		var failed []feedResult
+		for _, res := range results {
+			if res.Err != nil {
+				failed = append(failed, res)
+				continue
+			}
+			for _, item := range res.Feed.Items {
+				if seen.mark(item) {
+					marked++
+				}
+			}
+		}
+		fmt.Printf(Green+"✅ Marked %d items as read in %s\n"+Reset, marked, name)
+		printFeedErrors(os.Stdout, failed, len(results))
+	}
+
+	if err := seen.save(); err != nil {
+		fmt.Println("❌ Failed to save read items:", err)
+	}
+}
+
+// Mark-read command
+var newsMarkReadCmd = &cobra.Command{
+	Use:   "mark-read [category]",
+	Short: "Mark all items in a category (or every category) as read",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		category := ""
+		if len(args) == 1 {
+			category = args[0]
+		}
+		markNewsRead(category)
+	},
+}
+
+func init() {
+	newsCmd.AddCommand(newsMarkReadCmd)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func TestItemKey(t *testing.T) {
+	cases := []struct {
+		item gofeed.Item
+		want string
+	}{
+		{gofeed.Item{GUID: "id-1", Link: "https://a.example/1", Title: "One"}, "id-1"},
+		{gofeed.Item{Link: "https://a.example/1", Title: "One"}, "https://a.example/1"},
+		{gofeed.Item{Title: "One"}, "One"},
+	}
+	for _, c := range cases {
+		if got := itemKey(&c.item); got != c.want {
+			t.Errorf("itemKey(%+v) = %q, want %q", c.item, got, c.want)
+		}
+	}
+}
+
+func TestSeenStorePersists(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	first := &gofeed.Item{GUID: "id-1"}
+	second := &gofeed.Item{Link: "https://a.example/2"}
+
+	seen := loadSeenStore()
+	if seen.has(first) {
+		t.Fatal("fresh store already has an item")
+	}
+	if !seen.mark(first) || seen.mark(first) {
+		t.Error("mark should only report the first time an item is marked")
+	}
+	if err := seen.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := loadSeenStore()
+	if !reloaded.has(first) || reloaded.has(second) {
+		t.Errorf("unexpected items after reload: %v", reloaded.Items)
+	}
+}
+
+func TestSeenStoreForgetsOldMarkers(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	seen := loadSeenStore()
+	seen.Items["old"] = time.Now().Add(-seenRetention - time.Hour)
+	seen.Items["recent"] = time.Now().Add(-time.Hour)
+	if err := seen.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := loadSeenStore()
+	if _, ok := reloaded.Items["old"]; ok {
+		t.Error("marker past the retention window was kept")
+	}
+	if _, ok := reloaded.Items["recent"]; !ok {
+		t.Error("recent marker was dropped")
+	}
+}
@@ -0,0 +1,495 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Longest summary put into a served feed item
+const servedSummaryLength = 600
+
+// Merged items of one category, as last refreshed
+type servedCategory struct {
+	Items   []newsItem
+	Feeds   int
+	Failed  int
+	Updated time.Time
+}
+
+// Re-publishes each category as a merged feed over HTTP
+type newsServer struct {
+	cfg     *newsConfig
+	fetcher *feedFetcher
+	limit   int
+
+	mu         sync.RWMutex
+	categories map[string]*servedCategory
+}
+
+// Feeds of a category that may be served. Feeds fetched with credentials
+// are left out, since anyone who can reach the server can read its feeds.
+func (s *newsServer) publicFeeds(category string) []string {
+	var urls []string
+	for _, u := range s.cfg.sourceMap()[category] {
+		if !s.fetcher.options[u].authenticated() {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// Fetch every category and rebuild the merged item lists
+func (s *newsServer) refresh() {
+	for _, name := range s.cfg.categoryNames() {
+		urls := s.publicFeeds(name)
+		results := s.fetcher.fetchAll(urls)
+		if err := archiveResults(name, results); err != nil {
+			fmt.Println("⚠️ Could not archive items:", err)
+		}
+
+		filters, err := s.cfg.filtersFor(name, "")
+		if err != nil {
+			fmt.Println("⚠️", err)
+			filters = &newsFilterSet{}
+		}
+
+		served := &servedCategory{Feeds: len(urls), Updated: time.Now()}
+		var items []newsItem
+		for _, res := range results {
+			if res.Err != nil {
+				served.Failed++
+				continue
+			}
+			for _, item := range res.Feed.Items {
+				ni := newsItemFrom(name, res.URL, res.Feed, item)
+				if filters.allows(ni) {
+					items = append(items, ni)
+				}
+			}
+		}
+		served.Items = mergeNewsItems(items)
+		if len(served.Items) > s.limit {
+			served.Items = served.Items[:s.limit]
+		}
+
+		s.mu.Lock()
+		s.categories[name] = served
+		s.mu.Unlock()
+	}
+	fmt.Printf(Gray+"[%s] refreshed %d categories\n"+Reset, time.Now().Format("15:04"), len(s.cfg.Categories))
+}
+
+// Look up a category by name
+func (s *newsServer) category(name string) (*servedCategory, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.categories[name]
+	return c, ok
+}
+
+// Base URL of the server as seen by the client
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// Plain-text summary of an item, shortened for feeds
+func itemSummary(item newsItem) string {
+	if item.raw == nil {
+		return ""
+	}
+	text := htmlToText(item.raw.Description)
+	if text == "" {
+		text = htmlToText(item.raw.Content)
+	}
+	return truncateRunes(text, servedSummaryLength)
+}
+
+// Shorten text to at most max runes, adding an ellipsis if cut
+func truncateRunes(text string, max int) string {
+	if max <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
+
+// GUID for a served item that stays stable across refreshes
+func servedItemID(item newsItem) string {
+	if item.Link != "" {
+		return item.Link
+	}
+	return itemKey(item.raw)
+}
+
+// RSS 2.0 document
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	GUID        rssGUID    `xml:"guid"`
+	PubDate     string     `xml:"pubDate,omitempty"`
+	Description string     `xml:"description,omitempty"`
+	Source      *rssSource `xml:"source,omitempty"`
+	Categories  []string   `xml:"category"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type rssSource struct {
+	Title string `xml:",chardata"`
+	URL   string `xml:"url,attr"`
+}
+
+// Atom 1.0 document
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Link      atomLink    `xml:"link"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published,omitempty"`
+	Author    *atomAuthor `xml:"author,omitempty"`
+	Summary   string      `xml:"summary,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// JSON Feed 1.1 document
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentText   string           `json:"content_text"`
+	DatePublished string           `json:"date_published,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// Write a category as RSS 2.0
+func writeRSS(w http.ResponseWriter, name, base string, c *servedCategory) {
+	doc := rssDoc{Version: "2.0", Channel: rssChannel{
+		Title:         "brightside: " + name,
+		Link:          base + "/",
+		Description:   fmt.Sprintf("%s news merged from %d feeds", name, c.Feeds),
+		LastBuildDate: c.Updated.Format(time.RFC1123Z),
+	}}
+	for _, item := range c.Items {
+		ri := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{Value: servedItemID(item), IsPermaLink: false},
+			Description: itemSummary(item),
+			Source:      &rssSource{Title: item.Feed, URL: item.FeedURL},
+			Categories:  item.Sources,
+		}
+		if item.Published != nil {
+			ri.PubDate = item.Published.Format(time.RFC1123Z)
+		}
+		doc.Channel.Items = append(doc.Channel.Items, ri)
+	}
+	writeXML(w, "application/rss+xml; charset=utf-8", doc)
+}
+
+// Write a category as Atom
+func writeAtom(w http.ResponseWriter, name, base, self string, c *servedCategory) {
+	doc := atomFeed{
+		ID:      "urn:brightside:news:" + url.PathEscape(name),
+		Title:   "brightside: " + name,
+		Updated: c.Updated.UTC().Format(time.RFC3339),
+		Links:   []atomLink{{Href: self, Rel: "self"}, {Href: base + "/", Rel: "alternate"}},
+	}
+	for _, item := range c.Items {
+		updated := c.Updated
+		if item.Published != nil {
+			updated = *item.Published
+		}
+		entry := atomEntry{
+			ID:      servedItemID(item),
+			Title:   item.Title,
+			Link:    atomLink{Href: item.Link},
+			Updated: updated.UTC().Format(time.RFC3339),
+			Summary: itemSummary(item),
+		}
+		if item.Published != nil {
+			entry.Published = item.Published.UTC().Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	writeXML(w, "application/atom+xml; charset=utf-8", doc)
+}
+
+// Write a category as JSON Feed
+func writeJSONFeed(w http.ResponseWriter, name, base, self string, c *servedCategory) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       "brightside: " + name,
+		HomePageURL: base + "/",
+		FeedURL:     self,
+		Items:       []jsonFeedItem{},
+	}
+	for _, item := range c.Items {
+		ji := jsonFeedItem{
+			ID:          servedItemID(item),
+			URL:         item.Link,
+			Title:       item.Title,
+			ContentText: itemSummary(item),
+			Tags:        item.Sources,
+		}
+		if item.Published != nil {
+			ji.DatePublished = item.Published.UTC().Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			ji.Authors = []jsonFeedAuthor{{Name: item.Author}}
+		}
+		doc.Items = append(doc.Items, ji)
+	}
+	w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(doc)
+}
+
+// Write an XML document with its header
+func writeXML(w http.ResponseWriter, contentType string, doc any) {
+	w.Header().Set("Content-Type", contentType)
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(doc)
+}
+
+// Serve /feeds/<category>.xml, .atom and .json
+func (s *newsServer) handleFeed(w http.ResponseWriter, r *http.Request) {
+	file := r.PathValue("file")
+	var name, format string
+	for _, ext := range []string{".xml", ".atom", ".json"} {
+		if n, ok := strings.CutSuffix(file, ext); ok {
+			name, format = n, ext
+			break
+		}
+	}
+
+	c, ok := s.category(name)
+	if format == "" || !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	base := baseURL(r)
+	self := base + r.URL.EscapedPath()
+	switch format {
+	case ".xml":
+		writeRSS(w, name, base, c)
+	case ".atom":
+		writeAtom(w, name, base, self, c)
+	case ".json":
+		writeJSONFeed(w, name, base, self, c)
+	}
+}
+
+// Index page listing every category and its feeds
+var serveIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>brightside news</title>
+{{range .}}<link rel="alternate" type="application/rss+xml" title="{{.Name}}" href="/feeds/{{.Path}}.xml">
+{{end}}<style>
+body { font-family: system-ui, sans-serif; max-width: 40rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+li { margin: .5rem 0; }
+small { color: #777; }
+</style>
+</head>
+<body>
+<h1>📰 brightside news</h1>
+<ul>
+{{range .}}<li><strong>{{.Name}}</strong> — <a href="/feeds/{{.Path}}.xml">RSS</a> · <a href="/feeds/{{.Path}}.atom">Atom</a> · <a href="/feeds/{{.Path}}.json">JSON</a><br>
+<small>{{.Items}} items from {{.Feeds}} feeds{{if .Failed}}, {{.Failed}} failing{{end}} · updated {{.Updated}}</small></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// Serve the HTML index
+func (s *newsServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	type row struct {
+		Name, Path, Updated  string
+		Items, Feeds, Failed int
+	}
+	var rows []row
+	for _, name := range s.cfg.categoryNames() {
+		c, ok := s.category(name)
+		if !ok {
+			continue
+		}
+		rows = append(rows, row{
+			Name:    name,
+			Path:    url.PathEscape(name),
+			Updated: c.Updated.Format("2006-01-02 15:04"),
+			Items:   len(c.Items),
+			Feeds:   c.Feeds,
+			Failed:  c.Failed,
+		})
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	serveIndexTemplate.Execute(w, rows)
+}
+
+// Routes of the news server
+func (s *newsServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /feeds/{file...}", s.handleFeed)
+	return mux
+}
+
+// Serve flags
+var serveAddr string
+var serveRefresh time.Duration
+var serveLimit int
+
+// Serve the news categories as feeds until interrupted
+func serveNews() {
+	s := &newsServer{
+		cfg:        loadNewsConfigOrDefaults(),
+		fetcher:    newNewsFetcher(),
+		limit:      serveLimit,
+		categories: map[string]*servedCategory{},
+	}
+	if serveRefresh < time.Minute {
+		fmt.Println("❌ Refresh interval must be at least 1m")
+		return
+	}
+	// Never serve cache entries older than one refresh cycle
+	if s.fetcher.ttl > serveRefresh {
+		s.fetcher.ttl = serveRefresh
+	}
+
+	private := 0
+	for _, opts := range s.fetcher.options {
+		if opts.authenticated() {
+			private++
+		}
+	}
+	if private > 0 {
+		fmt.Printf("🔒 Not serving %s that need credentials\n", plural(private, "feed"))
+	}
+
+	fmt.Println("📡 Fetching feeds...")
+	s.refresh()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		ticker := time.NewTicker(serveRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.refresh()
+			}
+		}
+	}()
+
+	server := &http.Server{Addr: serveAddr, Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+
+	fmt.Printf(Green+"✅ Serving news on http://%s (Ctrl+C to stop)\n"+Reset, serveAddr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		fmt.Println("❌ Server error:", err)
+		return
+	}
+	fmt.Println("\n👋 Server stopped.")
+}
+
+var newsServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve each news category as a merged RSS, Atom and JSON feed",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		serveNews()
+	},
+}
+
+func init() {
+	newsServeCmd.Flags().StringVarP(&serveAddr, "addr", "a", ":8080", "Address to listen on")
+	newsServeCmd.Flags().DurationVarP(&serveRefresh, "refresh", "r", 15*time.Minute, "How often to refetch the feeds")
+	newsServeCmd.Flags().IntVarP(&serveLimit, "limit", "l", 50, "Maximum items per category feed")
+	newsCmd.AddCommand(newsServeCmd)
+}
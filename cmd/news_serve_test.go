@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func TestServeLeavesOutFeedsWithCredentials(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	srv, requests, _ := newTestFeedServer(t)
+	private := srv.URL + "/private"
+	f := newTestFetcher(t, 0)
+	f.options = map[string]*sourceHTTP{private: {Headers: map[string]string{"X-Api-Key": "secret"}}}
+	s := &newsServer{
+		cfg: &newsConfig{Categories: map[string]*newsCategory{
+			"Tech": {Sources: []newsSource{{URL: srv.URL}, {URL: private, HTTP: f.options[private]}}},
+		}},
+		fetcher:    f,
+		limit:      10,
+		categories: map[string]*servedCategory{},
+	}
+
+	s.refresh()
+	c, ok := s.category("Tech")
+	if !ok || c.Feeds != 1 || len(c.Items) != 1 {
+		t.Fatalf("expected one public feed to be served, got %+v", c)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("the feed with credentials was fetched for serving (%d requests)", requests.Load())
+	}
+}
+
+// News server with one refreshed category, served over HTTP
+func newTestNewsServer(t *testing.T, category string) *httptest.Server {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	feeds, _, _ := newTestFeedServer(t)
+	s := &newsServer{
+		cfg: &newsConfig{Categories: map[string]*newsCategory{
+			category: {Sources: []newsSource{{URL: feeds.URL + "/a"}, {URL: feeds.URL + "/b"}}},
+		}},
+		fetcher:    newTestFetcher(t, time.Hour),
+		limit:      10,
+		categories: map[string]*servedCategory{},
+	}
+	s.refresh()
+	srv := httptest.NewServer(s.handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func getBody(t *testing.T, u string) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestServeFeedFormats(t *testing.T) {
+	srv := newTestNewsServer(t, "Tech News")
+	base := srv.URL + "/feeds/" + url.PathEscape("Tech News")
+
+	cases := []struct {
+		ext, contentType, feedType string
+	}{
+		{".xml", "application/rss+xml", "rss"},
+		{".atom", "application/atom+xml", "atom"},
+		{".json", "application/feed+json", "json"},
+	}
+	for _, c := range cases {
+		resp, body := getBody(t, base+c.ext)
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), c.contentType) {
+			t.Errorf("%s: %s, %s", c.ext, resp.Status, resp.Header.Get("Content-Type"))
+			continue
+		}
+		feed, err := gofeed.NewParser().ParseString(body)
+		if err != nil {
+			t.Errorf("%s doesn't parse: %v", c.ext, err)
+			continue
+		}
+		// Both sources serve the same item, which is merged into one
+		if feed.FeedType != c.feedType || feed.Title != "brightside: Tech News" || len(feed.Items) != 1 {
+			t.Errorf("%s: %s feed %q with %d items", c.ext, feed.FeedType, feed.Title, len(feed.Items))
+			continue
+		}
+		if feed.Items[0].Title != "First" || feed.Items[0].Link != "https://example.com/1" {
+			t.Errorf("%s: unexpected item %+v", c.ext, feed.Items[0])
+		}
+	}
+}
+
+func TestServeIndexAndNotFound(t *testing.T) {
+	srv := newTestNewsServer(t, "Tech")
+
+	resp, body := getBody(t, srv.URL+"/")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `href="/feeds/Tech.atom"`) || !strings.Contains(body, "1 items from 2 feeds") {
+		t.Errorf("unexpected index (%s):\n%s", resp.Status, body)
+	}
+
+	for _, path := range []string{"/feeds/World.xml", "/feeds/Tech.html", "/feeds/Tech", "/other"} {
+		if resp, _ := getBody(t, srv.URL+path); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: %s, want 404", path, resp.Status)
+		}
+	}
+	resp, err := http.Post(srv.URL+"/feeds/Tech.xml", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST: %s, want 405", resp.Status)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	cases := []struct {
+		text string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"too long text", 8, "too lon…"},
+		{"héllo wörld", 6, "héllo…"},
+		{"anything", 0, ""},
+	}
+	for _, c := range cases {
+		if got := truncateRunes(c.text, c.max); got != c.want {
+			t.Errorf("truncateRunes(%q, %d) = %q, want %q", c.text, c.max, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/spf13/cobra"
+)
+
+// Sorted category names
+func sortedCategories(sources map[string][]string) []string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check that a feed URL is an absolute http(s) URL
+func checkFeedURL(feedURL string) error {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("URL must start with http:// or https://")
+	}
+	if u.Host == "" {
+		return errors.New("URL has no host")
+	}
+	return nil
+}
+
+// Fetch a feed straight from the network, bypassing the cache
+func probeFeed(feedURL string, opts *sourceHTTP) (*gofeed.Feed, error) {
+	fetcher := newNewsFetcher()
+	fetcher.cache = nil
+	fetcher.offline = false
+	fetcher.options = map[string]*sourceHTTP{feedURL: opts}
+	res := fetcher.fetch(newFeedParser(), feedURL)
+	return res.Feed, res.Err
+}
+
+// Load the news config for editing, reporting any error
+func loadNewsConfigOrReport() *newsConfig {
+	cfg, err := loadNewsConfig()
+	if err != nil {
+		fmt.Println("❌ Failed to load news sources:", err)
+		return nil
+	}
+	return cfg
+}
+
+// Save the news config, reporting any error
+func saveNewsConfigOrReport(cfg *newsConfig) bool {
+	if err := saveNewsConfig(cfg); err != nil {
+		fmt.Println("❌ Failed to save news sources:", err)
+		return false
+	}
+	return true
+}
+
+// List the configured news sources
+func listNewsSources(category string) {
+	cfg := loadNewsConfigOrReport()
+	if cfg == nil {
+		return
+	}
+
+	names := cfg.categoryNames()
+	if category != "" {
+		if _, exists := cfg.Categories[category]; !exists {
+			fmt.Println("❌ Category not found!")
+			return
+		}
+		names = []string{category}
+	}
+
+	for _, name := range names {
+		fmt.Printf(Blue+"📂 %s\n"+Reset, name)
+		for _, src := range cfg.Categories[name].Sources {
+			if src.Title != "" {
+				fmt.Printf(Cyan+"  🔹 %s "+Gray+"(%s)\n"+Reset, src.URL, src.Title)
+			} else {
+				fmt.Printf(Cyan+"  🔹 %s\n"+Reset, src.URL)
+			}
+		}
+	}
+}
+
+// Add a news source after checking it parses as a feed
+func addNewsSource(category, feedURL string) {
+	feedURL = strings.TrimSpace(feedURL)
+	if err := checkFeedURL(feedURL); err != nil {
+		fmt.Println("❌ Invalid URL:", err)
+		return
+	}
+
+	cfg := loadNewsConfigOrReport()
+	if cfg == nil {
+		return
+	}
+	if existing, src := cfg.find(feedURL); src != nil {
+		fmt.Printf("❌ Source already exists in %s\n", existing)
+		return
+	}
+
+	opts, err := sourceHTTPFromFlags()
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+
+	fmt.Println("🔍 Checking feed...")
+	feed, err := probeFeed(feedURL, opts)
+	if err != nil {
+		// Maybe it's a website rather than a feed: look for the feeds it links to
+		found, derr := discoverFeeds(feedURL, opts)
+		if derr != nil || len(found) == 0 {
+			fmt.Println("❌ Not a readable feed:", err)
+			return
+		}
+
+		choice, err := chooseDiscoveredFeed(found, newsAddFirst)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		fmt.Printf("📡 Using feed %s\n", choice.URL)
+		feedURL, feed = choice.URL, choice.Feed
+
+		if existing, src := cfg.find(feedURL); src != nil {
+			fmt.Printf("❌ Source already exists in %s\n", existing)
+			return
+		}
+	}
+
+	cfg.add(category, newsSource{URL: feedURL, Title: feed.Title, HTTP: opts})
+	if !saveNewsConfigOrReport(cfg) {
+		return
+	}
+	fmt.Printf(Green+"✅ Added %s (%d items) to %s\n"+Reset, feed.Title, len(feed.Items), category)
+}
+
+// Remove a news source
+func removeNewsSource(category, feedURL string) {
+	cfg := loadNewsConfigOrReport()
+	if cfg == nil {
+		return
+	}
+	if _, exists := cfg.Categories[category]; !exists {
+		fmt.Println("❌ Category not found!")
+		return
+	}
+	if _, removed := cfg.remove(category, feedURL); !removed {
+		fmt.Printf("❌ %s is not a source in %s\n", feedURL, category)
+		return
+	}
+
+	if !saveNewsConfigOrReport(cfg) {
+		return
+	}
+	fmt.Println(Green + "✅ Source removed successfully!" + Reset)
+}
+
+// Rename a category, keeping its sources
+func renameNewsCategory(oldName, newName string) {
+	cfg := loadNewsConfigOrReport()
+	if cfg == nil {
+		return
+	}
+	if _, exists := cfg.Categories[oldName]; !exists {
+		fmt.Println("❌ Category not found!")
+		return
+	}
+	if _, exists := cfg.Categories[newName]; exists {
+		fmt.Printf("❌ Category %s already exists\n", newName)
+		return
+	}
+
+	cfg.Categories[newName] = cfg.Categories[oldName]
+	delete(cfg.Categories, oldName)
+	if !saveNewsConfigOrReport(cfg) {
+		return
+	}
+	fmt.Printf(Green+"✅ Renamed %s to %s\n"+Reset, oldName, newName)
+}
+
+// Move a news source to another category
+func moveNewsSource(feedURL, category string) {
+	cfg := loadNewsConfigOrReport()
+	if cfg == nil {
+		return
+	}
+	from, found := cfg.find(feedURL)
+	if found == nil {
+		fmt.Println("❌ Source not found!")
+		return
+	}
+	if from == category {
+		fmt.Printf("✅ Source is already in %s\n", category)
+		return
+	}
+
+	src, _ := cfg.remove(from, feedURL)
+	cfg.add(category, src)
+	if !saveNewsConfigOrReport(cfg) {
+		return
+	}
+	fmt.Printf(Green+"✅ Moved source from %s to %s\n"+Reset, from, category)
+}
+
+// Fetch every configured source and report the ones that are dead.
+// Returns false if any source failed.
+func validateNewsSources(category string) bool {
+	cfg := loadNewsConfigOrReport()
+	if cfg == nil {
+		return false
+	}
+	sources := cfg.sourceMap()
+
+	names := cfg.categoryNames()
+	if category != "" {
+		if _, exists := sources[category]; !exists {
+			fmt.Println("❌ Category not found!")
+			return false
+		}
+		names = []string{category}
+	}
+
+	fetcher := newNewsFetcher()
+	fetcher.cache = nil
+	fetcher.offline = false
+
+	dead := 0
+	for _, name := range names {
+		fmt.Printf(Blue+"📂 %s\n"+Reset, name)
+		for _, src := range cfg.Categories[name].Sources {
+			if src.HTTP == nil {
+				continue
+			}
+			if err := src.HTTP.validate(); err != nil {
+				dead++
+				fmt.Printf("  ❌ %s: invalid http options: %v\n", src.URL, err)
+			}
+		}
+		for _, res := range fetcher.fetchAll(sources[name]) {
+			if res.Err != nil {
+				dead++
+				fmt.Printf("  ❌ %s: %v\n", res.URL, res.Err)
+				continue
+			}
+			fmt.Printf(Green+"  ✅ %s "+Gray+"(%s, %d items)\n"+Reset, res.URL, res.Feed.Title, len(res.Feed.Items))
+		}
+	}
+
+	if dead > 0 {
+		fmt.Printf("\n⚠️ %d dead sources found\n", dead)
+		return false
+	}
+	fmt.Println(Green + "\n✅ All sources are healthy!" + Reset)
+	return true
+}
+
+// Pick the first discovered feed instead of asking
+var newsAddFirst bool
+
+// News sources command tree
+var newsSourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Manage news sources",
+}
+
+var newsSourcesListCmd = &cobra.Command{
+	Use:   "list [category]",
+	Short: "List news sources",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		category := ""
+		if len(args) == 1 {
+			category = args[0]
+		}
+		listNewsSources(category)
+	},
+}
+
+var newsSourcesAddCmd = &cobra.Command{
+	Use:   "add [category] [url]",
+	Short: "Add a news source to a category",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		addNewsSource(args[0], args[1])
+	},
+}
+
+var newsSourcesRemoveCmd = &cobra.Command{
+	Use:   "remove [category] [url]",
+	Short: "Remove a news source from a category",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		removeNewsSource(args[0], args[1])
+	},
+}
+
+var newsSourcesRenameCmd = &cobra.Command{
+	Use:   "rename-category [old] [new]",
+	Short: "Rename a news category",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		renameNewsCategory(args[0], args[1])
+	},
+}
+
+var newsSourcesMoveCmd = &cobra.Command{
+	Use:   "move [url] [category]",
+	Short: "Move a news source to another category",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		moveNewsSource(args[0], args[1])
+	},
+}
+
+var newsSourcesValidateCmd = &cobra.Command{
+	Use:   "validate [category]",
+	Short: "Check every news source and report dead ones",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		category := ""
+		if len(args) == 1 {
+			category = args[0]
+		}
+		if !validateNewsSources(category) {
+			os.Exit(1)
+		}
+	},
+}
+
+// Old top-level commands, kept so existing scripts keep working
+var newsAddCmd = &cobra.Command{
+	Use:        "news-add [category] [url]",
+	Short:      "Add a news source to a category",
+	Args:       cobra.ExactArgs(2),
+	Deprecated: "use 'news sources add' instead",
+	Run:        newsSourcesAddCmd.Run,
+}
+
+var newsRemoveCmd = &cobra.Command{
+	Use:        "news-remove [category] [url]",
+	Short:      "Remove a news source from a category",
+	Args:       cobra.ExactArgs(2),
+	Deprecated: "use 'news sources remove' instead",
+	Run:        newsSourcesRemoveCmd.Run,
+}
+
+func init() {
+	newsSourcesAddCmd.Flags().BoolVar(&newsAddFirst, "first", false, "Use the first feed found when given a website URL")
+	newsAddCmd.Flags().BoolVar(&newsAddFirst, "first", false, "Use the first feed found when given a website URL")
+	newsSourcesAddCmd.Flags().StringVar(&sourceUserAgent, "user-agent", "", "User agent to send to this feed")
+	newsSourcesAddCmd.Flags().StringArrayVarP(&sourceHeaders, "header", "H", nil, "Extra request header (\"Name: value\", value may be env:NAME or cred:name)")
+	newsSourcesAddCmd.Flags().StringVar(&sourceAuthType, "auth", "", "Authentication (basic, bearer)")
+	newsSourcesAddCmd.Flags().StringVar(&sourceUsername, "username", "", "User name for basic auth")
+	newsSourcesAddCmd.Flags().StringVar(&sourceSecret, "secret", "", "Password or token, preferably as env:NAME or cred:name")
+	newsSourcesAddCmd.Flags().StringVar(&sourceProxy, "proxy", "", "Proxy URL for this feed")
+	newsSourcesAddCmd.Flags().DurationVar(&sourceTimeout, "timeout", 0, "Timeout for this feed (default: the --timeout flag)")
+	newsSourcesAddCmd.Flags().BoolVar(&sourceInsecure, "insecure", false, "Skip TLS certificate verification for this feed")
+	newsSourcesCmd.AddCommand(newsSourcesListCmd)
+	newsSourcesCmd.AddCommand(newsSourcesAddCmd)
+	newsSourcesCmd.AddCommand(newsSourcesRemoveCmd)
+	newsSourcesCmd.AddCommand(newsSourcesRenameCmd)
+	newsSourcesCmd.AddCommand(newsSourcesMoveCmd)
+	newsSourcesCmd.AddCommand(newsSourcesValidateCmd)
+	newsCmd.AddCommand(newsSourcesCmd)
+	rootCmd.AddCommand(newsAddCmd)
+	rootCmd.AddCommand(newsRemoveCmd)
+}
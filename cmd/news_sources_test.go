@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Point the news config at a file in a temporary directory
+func useTestNewsConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "news.json")
+	if content != "" {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := newsConfigFile
+	newsConfigFile = path
+	t.Cleanup(func() { newsConfigFile = old })
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	return path
+}
+
+func TestCheckFeedURL(t *testing.T) {
+	cases := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://example.com/feed", true},
+		{"http://example.com:8080/rss.xml", true},
+		{"example.com/feed", false},
+		{"ftp://example.com/feed", false},
+		{"https:///feed", false},
+		{"", false},
+		{"http://[::1", false},
+	}
+	for _, c := range cases {
+		if err := checkFeedURL(c.url); (err == nil) != c.ok {
+			t.Errorf("checkFeedURL(%q) = %v, want ok=%v", c.url, err, c.ok)
+		}
+	}
+}
+
+func TestLoadNewsConfigLegacyFormat(t *testing.T) {
+	useTestNewsConfig(t, `{"Tech": ["https://a.example/feed", "https://b.example/feed"]}`)
+
+	cfg, err := loadNewsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.sourceMap()["Tech"]; len(got) != 2 || got[1] != "https://b.example/feed" {
+		t.Errorf("unexpected sources: %v", got)
+	}
+
+	// Saving upgrades the file to the current format
+	if err := saveNewsConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := loadNewsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.Categories["Tech"].Sources) != 2 {
+		t.Errorf("sources lost after saving: %+v", reloaded.Categories)
+	}
+}
+
+func TestNewsConfigAddFindRemove(t *testing.T) {
+	cfg := &newsConfig{Categories: map[string]*newsCategory{}}
+	cfg.add("Tech", newsSource{URL: "https://a.example/feed"})
+	cfg.add("World", newsSource{URL: "https://w.example/feed"})
+
+	if category, src := cfg.find("https://w.example/feed"); category != "World" || src == nil {
+		t.Errorf("find = %q, %v", category, src)
+	}
+	if _, removed := cfg.remove("Tech", "https://w.example/feed"); removed {
+		t.Error("removed a source from a category it isn't in")
+	}
+	if _, removed := cfg.remove("Tech", "https://a.example/feed"); !removed {
+		t.Error("source wasn't removed")
+	}
+	if _, src := cfg.find("https://a.example/feed"); src != nil {
+		t.Error("removed source is still found")
+	}
+}
+
+func TestAddNewsSource(t *testing.T) {
+	srv, _, _ := newTestFeedServer(t)
+	useTestNewsConfig(t, `{"categories": {"Tech": {"sources": [{"url": "https://a.example/feed"}]}}}`)
+
+	addNewsSource("Tech", "not a url")
+	addNewsSource("World", "https://a.example/feed")
+	addNewsSource("World", "  "+srv.URL+"/rss  ")
+	addNewsSource("World", srv.URL+"/rss")
+
+	cfg, err := loadNewsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Categories["Tech"].Sources) != 1 {
+		t.Errorf("invalid URL was added: %+v", cfg.Categories["Tech"].Sources)
+	}
+	world := cfg.Categories["World"]
+	if world == nil || len(world.Sources) != 1 {
+		t.Fatalf("want exactly the test feed in World, got %+v", world)
+	}
+	if world.Sources[0].URL != srv.URL+"/rss" || world.Sources[0].Title != "Test" {
+		t.Errorf("unexpected source %+v", world.Sources[0])
+	}
+}
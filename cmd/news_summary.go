@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"strings"
+	"unicode"
+
+	"brightside-go/ui"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Plain-text summary of an item: HTML stripped, entities decoded and whitespace
+// collapsed, then cut to a number of sentences (if > 0) or characters.
+func summarize(item newsItem, chars, sentences int) string {
+	if item.raw == nil {
+		return ""
+	}
+	text := htmlToText(item.raw.Description)
+	if text == "" {
+		text = htmlToText(item.raw.Content)
+	}
+	if sentences > 0 {
+		return firstSentences(text, sentences)
+	}
+	return truncateRunes(text, chars)
+}
+
+// First n sentences of a text. Sentences end with . ! ? (or their full-width
+// forms) followed by a space or the end of the text.
+func firstSentences(text string, n int) string {
+	runes := []rune(text)
+	count := 0
+	for i, r := range runes {
+		if !strings.ContainsRune(".!?。！？", r) {
+			continue
+		}
+		// Full-width punctuation needs no space after it; ASCII does
+		if i+1 < len(runes) && r < unicode.MaxASCII && !unicode.IsSpace(runes[i+1]) {
+			continue
+		}
+		count++
+		if count == n {
+			return strings.TrimSpace(string(runes[:i+1]))
+		}
+	}
+	return text
+}
+
+// Author, date and age of an item for --wide output
+func itemDetails(item newsItem) []string {
+	var parts []string
+	if item.Author != "" {
+		parts = append(parts, item.Author)
+	}
+	if item.Published != nil {
+		parts = append(parts, item.Published.Local().Format("2006-01-02 15:04"), ui.RelativeAge(*item.Published))
+	}
+	return parts
+}
+
+// Shorten a line to the display width, counting wide characters as two columns
+func fitWidth(line string, width int) string {
+	return runewidth.Truncate(line, width, "…")
+}
+
+// Narrowest a title is cut to; below that the line is left to wrap
+const minTitleWidth = 20
+
+// Shorten a title to the columns its line has left after the used ones
+func fitTitle(title string, width, used int) string {
+	if width-used < minTitleWidth {
+		return title
+	}
+	return runewidth.Truncate(title, width-used, "…")
+}
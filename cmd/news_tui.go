@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"brightside-go/ui"
+)
+
+// Longest summary shown in the news browser preview
+const tuiSummaryLength = 2000
+
+// Feeds the news browser in the ui package from the configured categories
+type tuiNewsSource struct {
+	cfg     *newsConfig
+	fetcher *feedFetcher
+	seen    *seenStore
+
+	mu    sync.Mutex
+	items map[string]newsItem
+}
+
+// Create a news source for the browser
+func newTUINewsSource() *tuiNewsSource {
+	return &tuiNewsSource{
+		cfg:     loadNewsConfigOrDefaults(),
+		fetcher: newNewsFetcher(),
+		seen:    loadSeenStore(),
+		items:   map[string]newsItem{},
+	}
+}
+
+func (s *tuiNewsSource) Categories() []string {
+	return s.cfg.categoryNames()
+}
+
+// Fetch, filter and merge the items of a category, newest first
+func (s *tuiNewsSource) Items(category string, refresh bool) ([]ui.NewsItem, error) {
+	feeds, ok := s.cfg.sourceMap()[category]
+	if !ok {
+		return nil, fmt.Errorf("unknown category %s", category)
+	}
+	filters, err := s.cfg.filtersFor(category, "")
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher := *s.fetcher
+	if refresh {
+		fetcher.ttl = 0
+	}
+	results := fetcher.fetchAll(feeds)
+
+	var all []newsItem
+	var failed []string
+	for _, res := range results {
+		if res.Err != nil {
+			failed = append(failed, res.URL)
+			continue
+		}
+		for _, item := range res.Feed.Items {
+			if ni := newsItemFrom(category, res.URL, res.Feed, item); filters.allows(ni) {
+				all = append(all, ni)
+			}
+		}
+	}
+	if len(failed) > 0 && len(failed) == len(results) {
+		return nil, fmt.Errorf("all %d feeds failed", len(results))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []ui.NewsItem
+	for _, item := range mergeNewsItems(all) {
+		key := item.FeedURL + " " + itemKey(item.raw)
+		s.items[key] = item
+
+		ti := ui.NewsItem{
+			Key:     key,
+			Title:   item.Title,
+			Link:    item.Link,
+			Feed:    strings.Join(item.Sources, ", "),
+			Author:  item.Author,
+			Summary: truncateRunes(htmlToText(item.raw.Description), tuiSummaryLength),
+			Read:    s.seen.has(item.raw),
+		}
+		if ti.Feed == "" {
+			ti.Feed = item.Feed
+		}
+		if item.Published != nil {
+			ti.Published = *item.Published
+		}
+		out = append(out, ti)
+	}
+	return out, nil
+}
+
+// Mark items (and their duplicates from other feeds) as read
+func (s *tuiNewsSource) MarkRead(items []ui.NewsItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ti := range items {
+		if item, ok := s.items[ti.Key]; ok {
+			for _, raw := range item.rawItems() {
+				s.seen.mark(raw)
+			}
+		}
+	}
+	return s.seen.save()
+}
+
+// Start the interactive news browser, optionally in a category
+func browseNews(args []string) {
+	source := newTUINewsSource()
+	category := ""
+	if len(args) > 0 {
+		category = args[0]
+		if _, ok := source.cfg.Categories[category]; !ok {
+			fmt.Println("❌ Invalid category. Available categories:")
+			printCategories(source.cfg.sourceMap())
+			return
+		}
+	}
+	if err := ui.RunNewsBrowser(source, category); err != nil {
+		fmt.Println("❌ Error running the news browser:", err)
+	}
+}
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Longest a failing feed is left alone before it is retried
+const watchMaxBackoff = 6 * time.Hour
+
+// How long announced items are remembered
+const watchRetention = 30 * 24 * time.Hour
+
+// Something that tells the user about a new item
+type notifier interface {
+	notify(item newsItem) error
+}
+
+// Prints new items to the terminal, optionally ringing the bell
+type terminalNotifier struct {
+	bell bool
+}
+
+func (n terminalNotifier) notify(item newsItem) error {
+	bell := ""
+	if n.bell {
+		bell = "\a"
+	}
+	fmt.Printf(Gray+"[%s] "+Blue+"%s "+Green+"🆕 %s "+Cyan+"(%s)\n"+Reset+bell,
+		time.Now().Format("15:04"), item.Feed, item.Title, item.Link)
+	return nil
+}
+
+// Runs a shell command for each new item (e.g. notify-send). The item is passed in
+// $BRIGHTSIDE_TITLE, $BRIGHTSIDE_LINK, $BRIGHTSIDE_FEED and $BRIGHTSIDE_CATEGORY.
+type execNotifier struct {
+	command string
+}
+
+func (n execNotifier) notify(item newsItem) error {
+	cmd := exec.Command("sh", "-c", n.command)
+	cmd.Env = append(os.Environ(),
+		"BRIGHTSIDE_TITLE="+item.Title,
+		"BRIGHTSIDE_LINK="+item.Link,
+		"BRIGHTSIDE_FEED="+item.Feed,
+		"BRIGHTSIDE_CATEGORY="+item.Category,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// POSTs each new item as JSON to a URL
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (n webhookNotifier) notify(item newsItem) error {
+	body, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Per-feed polling state
+type watchFeedState struct {
+	Primed   bool      `json:"primed"`
+	Failures int       `json:"failures,omitempty"`
+	RetryAt  time.Time `json:"retry_at,omitzero"`
+	LastErr  string    `json:"last_error,omitempty"`
+}
+
+// What the watcher has already announced, kept between runs
+type watchState struct {
+	path      string
+	Announced map[string]time.Time       `json:"announced"`
+	Feeds     map[string]*watchFeedState `json:"feeds"`
+}
+
+// Load the watch state from the data directory
+func loadWatchState() *watchState {
+	s := &watchState{path: filepath.Join(brightsideDataDir(), "news_watch.json")}
+	if err := readJSONFile(s.path, s); err != nil {
+		fmt.Println("⚠️ Could not read watch state, starting fresh:", err)
+	}
+	if s.Announced == nil {
+		s.Announced = map[string]time.Time{}
+	}
+	if s.Feeds == nil {
+		s.Feeds = map[string]*watchFeedState{}
+	}
+	return s
+}
+
+// Save the watch state, forgetting old announcements
+func (s *watchState) save() error {
+	cutoff := time.Now().Add(-watchRetention)
+	for key, at := range s.Announced {
+		if at.Before(cutoff) {
+			delete(s.Announced, key)
+		}
+	}
+	return writeJSONFile(s.path, s)
+}
+
+// State for a feed, created on first use
+func (s *watchState) feed(url string) *watchFeedState {
+	fs, ok := s.Feeds[url]
+	if !ok {
+		fs = &watchFeedState{}
+		s.Feeds[url] = fs
+	}
+	return fs
+}
+
+// A feed being watched, with the category it came from
+type watchedFeed struct {
+	category string
+	url      string
+	filters  *newsFilterSet
+}
+
+// Polls feeds on a schedule and announces new items
+type newsWatcher struct {
+	feeds     []watchedFeed
+	interval  time.Duration
+	fetcher   *feedFetcher
+	notifiers []notifier
+	state     *watchState
+}
+
+// Fetch every feed that is due and announce items that haven't been seen.
+// The first successful poll of a feed only records its current items.
+func (w *newsWatcher) poll() []newsItem {
+	now := time.Now()
+
+	var due []watchedFeed
+	var urls []string
+	for _, f := range w.feeds {
+		if now.Before(w.state.feed(f.url).RetryAt) {
+			continue
+		}
+		due = append(due, f)
+		urls = append(urls, f.url)
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	results := w.fetcher.fetchAll(urls)
+	w.archive(due, results)
+
+	var fresh []newsItem
+	for i, res := range results {
+		f := due[i]
+		fs := w.state.feed(f.url)
+
+		if res.Err != nil {
+			fs.Failures++
+			fs.LastErr = res.Err.Error()
+			backoff := w.interval << min(fs.Failures, 16)
+			if backoff > watchMaxBackoff || backoff <= 0 {
+				backoff = watchMaxBackoff
+			}
+			fs.RetryAt = now.Add(backoff)
+			fmt.Printf("⚠️ %s failed (%d in a row), retrying in %s: %v\n", f.url, fs.Failures, backoff.Round(time.Second), res.Err)
+			continue
+		}
+		fs.Failures, fs.LastErr, fs.RetryAt = 0, "", time.Time{}
+
+		for _, item := range res.Feed.Items {
+			key := itemKey(item)
+			if _, done := w.state.Announced[key]; done {
+				continue
+			}
+			w.state.Announced[key] = now
+
+			ni := newsItemFrom(f.category, f.url, res.Feed, item)
+			if fs.Primed && f.filters.allows(ni) {
+				fresh = append(fresh, ni)
+			}
+		}
+		fs.Primed = true
+	}
+
+	// Announce oldest first so the newest ends up at the bottom
+	sort.SliceStable(fresh, func(i, j int) bool {
+		return itemTime(fresh[i]).Before(itemTime(fresh[j]))
+	})
+	for _, item := range fresh {
+		for _, n := range w.notifiers {
+			if err := n.notify(item); err != nil {
+				fmt.Println("⚠️ Notification failed:", err)
+			}
+		}
+	}
+
+	if err := w.state.save(); err != nil {
+		fmt.Println("❌ Failed to save watch state:", err)
+	}
+	return fresh
+}
+
+// Keep every fetched item in the news archive
+func (w *newsWatcher) archive(feeds []watchedFeed, results []feedResult) {
+	byCategory := map[string][]feedResult{}
+	for i, res := range results {
+		byCategory[feeds[i].category] = append(byCategory[feeds[i].category], res)
+	}
+	for category, res := range byCategory {
+		if err := archiveResults(category, res); err != nil {
+			fmt.Println("⚠️ Could not archive items:", err)
+			return
+		}
+	}
+}
+
+// Poll until interrupted (or once)
+func (w *newsWatcher) run(ctx context.Context, once bool) {
+	w.poll()
+	if once {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("\n👋 Stopped watching.")
+			return
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+// Watch flags
+var watchInterval time.Duration
+var watchOnce bool
+var watchBell bool
+var watchExec string
+var watchWebhook string
+var watchDownload bool
+
+// Watch news categories and announce new items as they arrive
+func watchNews(categories []string) {
+	if watchInterval < time.Minute && !watchOnce {
+		fmt.Println("❌ Interval must be at least 1m")
+		return
+	}
+
+	cfg := loadNewsConfigOrDefaults()
+	if len(categories) == 0 {
+		categories = cfg.categoryNames()
+	}
+
+	w := &newsWatcher{
+		interval: watchInterval,
+		fetcher:  newNewsFetcher(),
+		state:    loadWatchState(),
+	}
+	// Always revalidate: the cache still saves bandwidth through conditional GETs
+	w.fetcher.ttl = 0
+
+	for _, category := range categories {
+		cat, exists := cfg.Categories[category]
+		if !exists {
+			fmt.Printf("❌ Invalid category %s. Available categories:\n", category)
+			printCategories(cfg.sourceMap())
+			return
+		}
+		filters, err := cfg.filtersFor(category, "")
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		for _, src := range cat.Sources {
+			w.feeds = append(w.feeds, watchedFeed{category: category, url: src.URL, filters: filters})
+		}
+	}
+
+	w.notifiers = append(w.notifiers, terminalNotifier{bell: watchBell})
+	if watchExec != "" {
+		w.notifiers = append(w.notifiers, execNotifier{command: watchExec})
+	}
+	if watchWebhook != "" {
+		w.notifiers = append(w.notifiers, webhookNotifier{url: watchWebhook, client: &http.Client{Timeout: 10 * time.Second}})
+	}
+
+	if watchDownload {
+		rules, err := cfg.podcastRules()
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		w.notifiers = append(w.notifiers, podcastNotifier{dir: cfg.podcastDir(), rules: rules, log: loadDownloadLog()})
+	}
+
+	fmt.Printf("👀 Watching %d feeds every %s (Ctrl+C to stop)...\n", len(w.feeds), watchInterval)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	w.run(ctx, watchOnce)
+}
+
+var newsWatchCmd = &cobra.Command{
+	Use:   "watch [category...]",
+	Short: "Keep polling news feeds and announce new items",
+	Run: func(cmd *cobra.Command, args []string) {
+		watchNews(args)
+	},
+}
+
+func init() {
+	newsWatchCmd.Flags().DurationVarP(&watchInterval, "interval", "i", 10*time.Minute, "How often to poll the feeds")
+	newsWatchCmd.Flags().BoolVar(&watchOnce, "once", false, "Poll once and exit (e.g. from cron)")
+	newsWatchCmd.Flags().BoolVar(&watchBell, "bell", false, "Ring the terminal bell for new items")
+	newsWatchCmd.Flags().StringVar(&watchExec, "exec", "", "Shell command to run for each new item (gets $BRIGHTSIDE_TITLE, $BRIGHTSIDE_LINK, ...)")
+	newsWatchCmd.Flags().BoolVar(&watchDownload, "download", false, "Download new episodes matching the podcast auto-download rules")
+	newsWatchCmd.Flags().StringVar(&watchWebhook, "webhook", "", "URL to POST each new item to as JSON")
+	newsCmd.AddCommand(newsWatchCmd)
+}
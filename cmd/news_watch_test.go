@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Remembers the items it was told about
+type recordingNotifier struct {
+	titles []string
+}
+
+func (n *recordingNotifier) notify(item newsItem) error {
+	n.titles = append(n.titles, item.Title)
+	return nil
+}
+
+// Feed server whose items and health can be changed between polls
+type testWatchFeed struct {
+	mu       sync.Mutex
+	items    []string
+	failing  bool
+	requests atomic.Int32
+}
+
+func (f *testWatchFeed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests.Add(1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing {
+		http.Error(w, "down", http.StatusInternalServerError)
+		return
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0"?><rss version="2.0"><channel><title>Watched</title>`)
+	for _, title := range f.items {
+		fmt.Fprintf(&b, "<item><title>%s</title><link>https://example.com/%s</link></item>", title, title)
+	}
+	b.WriteString("</channel></rss>")
+	w.Write([]byte(b.String()))
+}
+
+func (f *testWatchFeed) set(failing bool, items ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failing, f.items = failing, items
+}
+
+func TestWatcherAnnouncesOnlyNewItems(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	feed := &testWatchFeed{items: []string{"Old"}}
+	srv := httptest.NewServer(feed)
+	defer srv.Close()
+
+	rec := &recordingNotifier{}
+	w := &newsWatcher{
+		feeds:     []watchedFeed{{category: "Tech", url: srv.URL, filters: &newsFilterSet{}}},
+		interval:  time.Minute,
+		fetcher:   newTestFetcher(t, 0),
+		notifiers: []notifier{rec},
+		state:     loadWatchState(),
+	}
+
+	// The first poll only records what the feed already has
+	if fresh := w.poll(); len(fresh) != 0 {
+		t.Errorf("first poll announced %d items", len(fresh))
+	}
+
+	feed.set(false, "Old", "New")
+	w.poll()
+	if len(rec.titles) != 1 || rec.titles[0] != "New" {
+		t.Errorf("announced %v, want [New]", rec.titles)
+	}
+
+	// Announcements survive a restart
+	w.state = loadWatchState()
+	w.poll()
+	if len(rec.titles) != 1 {
+		t.Errorf("announced again after reloading the state: %v", rec.titles)
+	}
+}
+
+func TestWatcherBacksOffFailingFeeds(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	feed := &testWatchFeed{failing: true}
+	srv := httptest.NewServer(feed)
+	defer srv.Close()
+
+	w := &newsWatcher{
+		feeds:    []watchedFeed{{category: "Tech", url: srv.URL, filters: &newsFilterSet{}}},
+		interval: time.Minute,
+		fetcher:  newTestFetcher(t, 0),
+		state:    loadWatchState(),
+	}
+
+	start := time.Now()
+	w.poll()
+	fs := w.state.feed(srv.URL)
+	if fs.Failures != 1 || fs.LastErr == "" {
+		t.Fatalf("failure not recorded: %+v", fs)
+	}
+	if wait := fs.RetryAt.Sub(start); wait < 2*time.Minute || wait > 2*time.Minute+time.Second {
+		t.Errorf("retrying after %s, want 2m", wait)
+	}
+
+	// Not polled again until the backoff has passed
+	requests := feed.requests.Load()
+	w.poll()
+	if feed.requests.Load() != requests {
+		t.Error("feed was fetched during its backoff")
+	}
+
+	fs.Failures = 20
+	fs.RetryAt = time.Time{}
+	w.poll()
+	if wait := time.Until(fs.RetryAt); wait > watchMaxBackoff {
+		t.Errorf("backoff of %s is over the maximum", wait)
+	}
+
+	feed.set(false, "Back")
+	fs.RetryAt = time.Time{}
+	w.poll()
+	if fs.Failures != 0 || fs.LastErr != "" || !fs.RetryAt.IsZero() {
+		t.Errorf("recovery didn't reset the backoff: %+v", fs)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got newsItem
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("content type %q", r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	n := webhookNotifier{url: srv.URL, client: srv.Client()}
+	if err := n.notify(newsItem{Title: "Hello", Link: "https://a.example/1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Hello" || got.Link != "https://a.example/1" {
+		t.Errorf("webhook received %+v", got)
+	}
+
+	status = http.StatusBadGateway
+	if err := n.notify(newsItem{Title: "Hello"}); err == nil {
+		t.Error("failed webhook gave no error")
+	}
+}
+
+func TestExecNotifier(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	n := execNotifier{command: `printf '%s|%s|%s|%s' "$BRIGHTSIDE_TITLE" "$BRIGHTSIDE_LINK" "$BRIGHTSIDE_FEED" "$BRIGHTSIDE_CATEGORY" > ` + out}
+	if err := n.notify(newsItem{Title: "It's here", Link: "https://a.example/1", Feed: "Feed", Category: "Tech"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "It's here|https://a.example/1|Feed|Tech" {
+		t.Errorf("command saw %q", data)
+	}
+
+	if err := (execNotifier{command: "exit 3"}).notify(newsItem{}); err == nil {
+		t.Error("failing command gave no error")
+	}
+}
@@ -25,7 +25,8 @@ var setupCmd = &cobra.Command{
 }
 
 func runSetup() {
-	fmt.Println("🚀 Running Brightside-Go Setup...\n")
+	fmt.Println("🚀 Running Brightside-Go Setup...")
+	fmt.Println()
 
 	if resetFlag {
 		resetInstallation()
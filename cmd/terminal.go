@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/mattn/go-runewidth"
+)
+
+// Whether stdout is an interactive terminal
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(os.Stdout.Fd())
+}
+
+// Terminal size, falling back to $COLUMNS/$LINES and then 80x24
+func terminalSize() (int, int) {
+	if w, h, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 && h > 0 {
+		return w, h
+	}
+	w, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	h, _ := strconv.Atoi(os.Getenv("LINES"))
+	if w <= 0 {
+		w = 80
+	}
+	if h <= 0 {
+		h = 24
+	}
+	return w, h
+}
+
+// Word-wrap text to the given display width. The first line starts with
+// prefix and following lines with indent. Widths account for wide (CJK) characters.
+func wrapText(text string, width int, prefix, indent string) []string {
+	var lines []string
+	line := prefix
+	lineWidth := runewidth.StringWidth(prefix)
+	empty := true
+
+	for _, word := range strings.Fields(text) {
+		w := runewidth.StringWidth(word)
+		if !empty && lineWidth+1+w > width {
+			lines = append(lines, line)
+			line, lineWidth, empty = indent, runewidth.StringWidth(indent), true
+		}
+		if !empty {
+			line += " "
+			lineWidth++
+		}
+		// Break words that can't fit on a line of their own
+		for lineWidth+w > width && w > 1 && width > lineWidth {
+			head := runewidth.Truncate(word, width-lineWidth, "")
+			if head == "" {
+				break
+			}
+			lines = append(lines, line+head)
+			word = strings.TrimPrefix(word, head)
+			w = runewidth.StringWidth(word)
+			line, lineWidth = indent, runewidth.StringWidth(indent)
+		}
+		line += word
+		lineWidth += w
+		empty = false
+	}
+	if !empty || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -13,6 +13,9 @@ type model struct {
 	title    string
 	selected int
 	options  []string
+	news     NewsSource
+	child    tea.Model
+	size     tea.WindowSizeMsg
 }
 
 // Initialize the UI
@@ -22,6 +25,20 @@ func (m model) Init() tea.Cmd {
 
 // Handle Keyboard Input
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.size = size
+	}
+	// An open screen (like the news browser) gets all input until it hands back control
+	if m.child != nil {
+		if _, ok := msg.(newsBackMsg); ok {
+			m.child = nil
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.child, cmd = m.child.Update(msg)
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -37,6 +54,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.selected--
 			}
 		case "enter": // Select option
+			if m.options[m.selected] == "📰 News Feeds" && m.news != nil {
+				m.child = NewNewsBrowser(m.news, "", true)
+				m.child, _ = m.child.Update(m.size)
+				return m, m.child.Init()
+			}
 			fmt.Println("\n✅ Jack: Executing", m.options[m.selected])
 		}
 	}
@@ -45,6 +67,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // Render UI
 func (m model) View() string {
+	if m.child != nil {
+		return m.child.View()
+	}
+
 	title := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color("#ff66b2")).
@@ -63,11 +89,12 @@ func (m model) View() string {
 }
 
 // Start the UI
-func StartDashboard() {
+func StartDashboard(news NewsSource) {
 	model := model{
 		title:    "Brightside Jack",
 		selected: 0,
 		options:  []string{"📡 Live Twitch Chat", "🖥 System Stats", "📰 News Feeds", "🤖 Jack AI", "❌ Exit"},
+		news:     news,
 	}
 
 	if _, err := tea.NewProgram(model).Run(); err != nil {
@@ -0,0 +1,420 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// A headline shown in the news browser
+type NewsItem struct {
+	Key       string
+	Title     string
+	Link      string
+	Feed      string
+	Author    string
+	Summary   string
+	Published time.Time
+	Read      bool
+}
+
+// Where the news browser gets its data from (implemented by the news command)
+type NewsSource interface {
+	Categories() []string
+	Items(category string, refresh bool) ([]NewsItem, error)
+	MarkRead(items []NewsItem) error
+}
+
+// News browser styles
+var (
+	newsTitleStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#ff66b2"))
+	newsMetaStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	newsLinkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#00afd7")).Underline(true)
+	newsPreviewStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("62")).Padding(0, 1)
+	newsErrorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+)
+
+// Extra keys shown in the item list help
+var newsKeys = struct {
+	open, markRead, markAll, refresh, back, scrollDown, scrollUp key.Binding
+}{
+	open:       key.NewBinding(key.WithKeys("o", "enter"), key.WithHelp("o", "open")),
+	markRead:   key.NewBinding(key.WithKeys("m"), key.WithHelp("m", "mark read")),
+	markAll:    key.NewBinding(key.WithKeys("M"), key.WithHelp("M", "mark all read")),
+	refresh:    key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh")),
+	back:       key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
+	scrollDown: key.NewBinding(key.WithKeys("J"), key.WithHelp("J/K", "scroll preview")),
+	scrollUp:   key.NewBinding(key.WithKeys("K")),
+}
+
+// Sent when the items of a category have been loaded
+type newsLoadedMsg struct {
+	category string
+	items    []NewsItem
+	err      error
+}
+
+// Sent by an embedded browser when the user leaves it
+type newsBackMsg struct{}
+
+// A category in the category list
+type categoryEntry string
+
+func (c categoryEntry) FilterValue() string { return string(c) }
+func (c categoryEntry) Title() string       { return "📰 " + string(c) }
+func (c categoryEntry) Description() string { return "" }
+
+// An item in the item list
+type newsEntry struct {
+	NewsItem
+}
+
+func (e newsEntry) FilterValue() string { return e.NewsItem.Title + " " + e.Feed }
+
+func (e newsEntry) Title() string {
+	if e.Read {
+		return "  " + e.NewsItem.Title
+	}
+	return "● " + e.NewsItem.Title
+}
+
+func (e newsEntry) Description() string {
+	if e.Published.IsZero() {
+		return "  " + e.Feed
+	}
+	return "  " + e.Feed + " · " + RelativeAge(e.Published)
+}
+
+// Which list has the focus
+type newsScreen int
+
+const (
+	categoryScreen newsScreen = iota
+	itemScreen
+)
+
+// Browse categories and their headlines
+type NewsBrowser struct {
+	source     NewsSource
+	embedded   bool
+	screen     newsScreen
+	category   string
+	categories list.Model
+	items      list.Model
+	preview    viewport.Model
+	width      int
+	height     int
+}
+
+// Create a news browser, optionally opening a category straight away.
+// An embedded browser hands control back instead of quitting.
+func NewNewsBrowser(source NewsSource, category string, embedded bool) *NewsBrowser {
+	var cats []list.Item
+	for _, name := range source.Categories() {
+		cats = append(cats, categoryEntry(name))
+	}
+	catDelegate := list.NewDefaultDelegate()
+	catDelegate.ShowDescription = false
+	categories := list.New(cats, catDelegate, 0, 0)
+	categories.Title = "📰 News categories"
+	categories.KeyMap.Quit.SetEnabled(false)
+
+	items := list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	items.KeyMap.Quit.SetEnabled(false)
+	items.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{newsKeys.open, newsKeys.markRead, newsKeys.refresh, newsKeys.back}
+	}
+	items.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{newsKeys.open, newsKeys.markRead, newsKeys.markAll, newsKeys.refresh, newsKeys.back, newsKeys.scrollDown}
+	}
+
+	b := &NewsBrowser{source: source, embedded: embedded, categories: categories, items: items, preview: viewport.New(0, 0)}
+	if category != "" {
+		b.screen = itemScreen
+		b.category = category
+	}
+	return b
+}
+
+// Load the initial category, if any
+func (b *NewsBrowser) Init() tea.Cmd {
+	if b.screen == itemScreen {
+		return b.load(false)
+	}
+	return nil
+}
+
+// Fetch the items of the current category in the background
+func (b *NewsBrowser) load(refresh bool) tea.Cmd {
+	b.items.Title = "📰 " + b.category
+	status := b.items.NewStatusMessage("📡 Loading...")
+	source, category := b.source, b.category
+	return tea.Batch(status, func() tea.Msg {
+		items, err := source.Items(category, refresh)
+		return newsLoadedMsg{category: category, items: items, err: err}
+	})
+}
+
+// Leave the browser (or go back to the dashboard when embedded)
+func (b *NewsBrowser) quit() tea.Cmd {
+	if b.embedded {
+		return func() tea.Msg { return newsBackMsg{} }
+	}
+	return tea.Quit
+}
+
+// Handle keys, window size and loaded items
+func (b *NewsBrowser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.width, b.height = msg.Width, msg.Height
+		b.resize()
+		return b, nil
+
+	case newsLoadedMsg:
+		if msg.category != b.category {
+			return b, nil
+		}
+		if msg.err != nil {
+			return b, b.items.NewStatusMessage(newsErrorStyle.Render("❌ " + msg.err.Error()))
+		}
+		entries := make([]list.Item, len(msg.items))
+		for i, item := range msg.items {
+			entries[i] = newsEntry{item}
+		}
+		cmd := b.items.SetItems(entries)
+		b.updatePreview()
+		return b, tea.Batch(cmd, b.items.NewStatusMessage(fmt.Sprintf("%d items, %d unread", len(msg.items), unreadCount(msg.items))))
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return b, tea.Quit
+		}
+		if b.screen == categoryScreen {
+			return b.updateCategories(msg)
+		}
+		return b.updateItems(msg)
+	}
+
+	var cmd tea.Cmd
+	if b.screen == categoryScreen {
+		b.categories, cmd = b.categories.Update(msg)
+	} else {
+		b.items, cmd = b.items.Update(msg)
+	}
+	return b, cmd
+}
+
+// Keys on the category list
+func (b *NewsBrowser) updateCategories(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if b.categories.FilterState() != list.Filtering {
+		switch msg.String() {
+		case "q":
+			return b, b.quit()
+		case "esc":
+			if b.categories.FilterState() == list.Unfiltered {
+				return b, b.quit()
+			}
+		case "enter":
+			selected, ok := b.categories.SelectedItem().(categoryEntry)
+			if !ok {
+				return b, nil
+			}
+			b.screen = itemScreen
+			b.category = string(selected)
+			b.items.ResetFilter()
+			b.items.ResetSelected()
+			return b, tea.Batch(b.items.SetItems(nil), b.load(false))
+		}
+	}
+
+	var cmd tea.Cmd
+	b.categories, cmd = b.categories.Update(msg)
+	return b, cmd
+}
+
+// Keys on the item list
+func (b *NewsBrowser) updateItems(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if b.items.FilterState() != list.Filtering {
+		entry, selected := b.items.SelectedItem().(newsEntry)
+		switch msg.String() {
+		case "q":
+			return b, b.quit()
+		case "esc":
+			if b.items.FilterState() == list.Unfiltered {
+				b.screen = categoryScreen
+				b.category = ""
+				return b, nil
+			}
+		case "o", "enter":
+			if selected {
+				if err := openBrowser(entry.Link); err != nil {
+					return b, b.items.NewStatusMessage(newsErrorStyle.Render("❌ " + err.Error()))
+				}
+				return b, b.markRead(entry)
+			}
+			return b, nil
+		case "m":
+			if selected {
+				return b, b.markRead(entry)
+			}
+			return b, nil
+		case "M":
+			var entries []newsEntry
+			for _, item := range b.items.VisibleItems() {
+				entries = append(entries, item.(newsEntry))
+			}
+			return b, b.markRead(entries...)
+		case "r":
+			return b, b.load(true)
+		case "J":
+			b.preview.LineDown(3)
+			return b, nil
+		case "K":
+			b.preview.LineUp(3)
+			return b, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	b.items, cmd = b.items.Update(msg)
+	b.updatePreview()
+	return b, cmd
+}
+
+// Mark entries as read and update them in the list
+func (b *NewsBrowser) markRead(entries ...newsEntry) tea.Cmd {
+	var unread []NewsItem
+	for _, e := range entries {
+		if !e.Read {
+			unread = append(unread, e.NewsItem)
+		}
+	}
+	if len(unread) == 0 {
+		return nil
+	}
+	if err := b.source.MarkRead(unread); err != nil {
+		return b.items.NewStatusMessage(newsErrorStyle.Render("❌ " + err.Error()))
+	}
+
+	read := map[string]bool{}
+	for _, item := range unread {
+		read[item.Key] = true
+	}
+	var cmds []tea.Cmd
+	for i, item := range b.items.Items() {
+		if e := item.(newsEntry); read[e.Key] {
+			e.Read = true
+			cmds = append(cmds, b.items.SetItem(i, e))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// Size the lists and the preview to the window
+func (b *NewsBrowser) resize() {
+	b.categories.SetSize(b.width, b.height)
+	listWidth := b.width * 45 / 100
+	b.items.SetSize(listWidth, b.height)
+	b.preview.Width = max(b.width-listWidth-newsPreviewStyle.GetHorizontalFrameSize(), 10)
+	b.preview.Height = max(b.height-newsPreviewStyle.GetVerticalFrameSize(), 3)
+	b.updatePreview()
+}
+
+// Show the selected item in the preview pane
+func (b *NewsBrowser) updatePreview() {
+	entry, ok := b.items.SelectedItem().(newsEntry)
+	if !ok {
+		b.preview.SetContent("")
+		return
+	}
+
+	width := b.preview.Width
+	meta := []string{entry.Feed}
+	if entry.Author != "" {
+		meta = append(meta, entry.Author)
+	}
+	if !entry.Published.IsZero() {
+		meta = append(meta, entry.Published.Local().Format("2006-01-02 15:04")+" ("+RelativeAge(entry.Published)+")")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(newsTitleStyle.Width(width).Render(entry.NewsItem.Title) + "\n")
+	sb.WriteString(newsMetaStyle.Width(width).Render(strings.Join(meta, " · ")) + "\n\n")
+	if entry.Summary != "" {
+		sb.WriteString(lipgloss.NewStyle().Width(width).Render(entry.Summary) + "\n\n")
+	}
+	sb.WriteString(newsLinkStyle.Render(entry.Link))
+	b.preview.SetContent(sb.String())
+	b.preview.GotoTop()
+}
+
+// Render the current screen
+func (b *NewsBrowser) View() string {
+	if b.screen == categoryScreen {
+		return b.categories.View()
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, b.items.View(), newsPreviewStyle.Render(b.preview.View()))
+}
+
+// Number of unread items
+func unreadCount(items []NewsItem) int {
+	n := 0
+	for _, item := range items {
+		if !item.Read {
+			n++
+		}
+	}
+	return n
+}
+
+// RelativeAge formats the age of a timestamp: "just now", "5m ago", "3h ago",
+// "2d ago" or "4mo ago"
+func RelativeAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 60*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	default:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+	}
+}
+
+// Open a link in the default browser
+func openBrowser(link string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", link)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the opener once it exits so it doesn't linger as a zombie
+	go cmd.Wait()
+	return nil
+}
+
+// Run the news browser full screen
+func RunNewsBrowser(source NewsSource, category string) error {
+	_, err := tea.NewProgram(NewNewsBrowser(source, category, false), tea.WithAltScreen()).Run()
+	return err
+}
@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// In-memory news source that records what gets marked read
+type testNewsSource struct {
+	items   map[string][]NewsItem
+	marked  []string
+	markErr error
+}
+
+func (s *testNewsSource) Categories() []string { return []string{"Tech", "World"} }
+
+func (s *testNewsSource) Items(category string, refresh bool) ([]NewsItem, error) {
+	return s.items[category], nil
+}
+
+func (s *testNewsSource) MarkRead(items []NewsItem) error {
+	if s.markErr != nil {
+		return s.markErr
+	}
+	for _, item := range items {
+		s.marked = append(s.marked, item.Key)
+	}
+	return nil
+}
+
+func newTestNewsSource() *testNewsSource {
+	return &testNewsSource{items: map[string][]NewsItem{
+		"Tech": {
+			{Key: "a", Title: "A", Feed: "Feed"},
+			{Key: "b", Title: "B", Feed: "Feed", Read: true},
+			{Key: "c", Title: "C", Feed: "Feed"},
+		},
+	}}
+}
+
+func keyMsg(s string) tea.KeyMsg {
+	if s == "esc" {
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// Open a browser on a category and deliver its items
+func openTestBrowser(t *testing.T, source NewsSource, category string) *NewsBrowser {
+	t.Helper()
+	b := NewNewsBrowser(source, category, false)
+	b.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	items, err := source.Items(category, false)
+	b.Update(newsLoadedMsg{category: category, items: items, err: err})
+	return b
+}
+
+func readKeys(b *NewsBrowser) map[string]bool {
+	read := map[string]bool{}
+	for _, item := range b.items.Items() {
+		if e := item.(newsEntry); e.Read {
+			read[e.Key] = true
+		}
+	}
+	return read
+}
+
+func TestNewsBrowserMarkRead(t *testing.T) {
+	source := newTestNewsSource()
+	b := openTestBrowser(t, source, "Tech")
+	if len(b.items.Items()) != 3 {
+		t.Fatalf("got %d items, want 3", len(b.items.Items()))
+	}
+
+	b.Update(keyMsg("m"))
+	if len(source.marked) != 1 || source.marked[0] != "a" || !readKeys(b)["a"] {
+		t.Errorf("m marked %v", source.marked)
+	}
+
+	// Items that are already read aren't sent again
+	b.Update(keyMsg("M"))
+	if len(source.marked) != 2 || source.marked[1] != "c" || len(readKeys(b)) != 3 {
+		t.Errorf("M marked %v", source.marked)
+	}
+}
+
+func TestNewsBrowserMarkReadError(t *testing.T) {
+	source := newTestNewsSource()
+	source.markErr = errors.New("disk full")
+	b := openTestBrowser(t, source, "Tech")
+
+	b.Update(keyMsg("m"))
+	if len(readKeys(b)) != 1 {
+		t.Error("item shown as read although saving failed")
+	}
+}
+
+func TestNewsBrowserIgnoresStaleLoads(t *testing.T) {
+	b := openTestBrowser(t, newTestNewsSource(), "World")
+	b.Update(newsLoadedMsg{category: "Tech", items: newTestNewsSource().items["Tech"]})
+	if len(b.items.Items()) != 0 {
+		t.Error("items of another category replaced the list")
+	}
+}
+
+func TestNewsBrowserBackAndQuit(t *testing.T) {
+	b := openTestBrowser(t, newTestNewsSource(), "Tech")
+	b.Update(keyMsg("esc"))
+	if b.screen != categoryScreen || b.category != "" {
+		t.Fatal("esc didn't go back to the categories")
+	}
+
+	_, cmd := b.Update(keyMsg("q"))
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("q didn't quit")
+	}
+
+	embedded := NewNewsBrowser(newTestNewsSource(), "", true)
+	_, cmd = embedded.Update(keyMsg("q"))
+	if _, ok := cmd().(newsBackMsg); !ok {
+		t.Error("q in an embedded browser didn't hand back control")
+	}
+}
+
+func TestRelativeAge(t *testing.T) {
+	cases := []struct {
+		age  time.Duration
+		want string
+	}{
+		{10 * time.Second, "just now"},
+		{5 * time.Minute, "5m ago"},
+		{3 * time.Hour, "3h ago"},
+		{50 * time.Hour, "2d ago"},
+		{90 * 24 * time.Hour, "3mo ago"},
+	}
+	for _, c := range cases {
+		if got := RelativeAge(time.Now().Add(-c.age)); got != c.want {
+			t.Errorf("RelativeAge(-%s) = %q, want %q", c.age, got, c.want)
+		}
+	}
+}